@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/zopu/tracey/internal/bookmarks"
+	"github.com/zopu/tracey/internal/config"
+)
+
+const bookmarksUsage = `Usage:
+  tracey bookmarks [list]          List bookmarked traces
+  tracey bookmarks export [-format markdown|json] [-tag <tag>] [-since <duration>] [-o <file>]
+                                   Export bookmarks as an incident summary`
+
+// runBookmarks implements the bookmarks subcommand, for the traces
+// bookmarked in the TUI.
+func runBookmarks(cfg config.App, args []string) error {
+	bm, err := loadBookmarks(cfg)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return listBookmarks(bm.Items)
+	}
+	switch args[0] {
+	case "list":
+		return listBookmarks(bm.Items)
+	case "export":
+		return exportBookmarks(bm.Items, args[1:])
+	}
+	return errors.New(bookmarksUsage)
+}
+
+func loadBookmarks(cfg config.App) (*bookmarks.Bookmarks, error) {
+	path := cfg.BookmarksFile
+	if path == "" {
+		var err error
+		if path, err = bookmarks.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return bookmarks.Load(path)
+}
+
+func listBookmarks(items []bookmarks.Bookmark) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TRACE\tSTART\tREQUEST\tTAGS\tNOTE")
+	for _, bm := range items {
+		start := ""
+		if !bm.StartTime.IsZero() {
+			start = bm.StartTime.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			bm.TraceID, start, strings.TrimSpace(bm.Method+" "+bm.Path), strings.Join(bm.Tags, ","), bm.Note)
+	}
+	return w.Flush()
+}
+
+func exportBookmarks(items []bookmarks.Bookmark, args []string) error {
+	flags := flag.NewFlagSet("bookmarks export", flag.ContinueOnError)
+	format := flags.String("format", "markdown", "markdown or json")
+	tag := flags.String("tag", "", "only export bookmarks with this tag")
+	since := flags.Duration("since", 0, "only export bookmarks added in this long, e.g. 24h")
+	out := flags.String("o", "", "write to a file rather than stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	items = lo.Filter(items, func(bm bookmarks.Bookmark, _ int) bool {
+		return (*tag == "" || bm.HasTag(*tag)) && (*since == 0 || time.Since(bm.Added) <= *since)
+	})
+	var content string
+	switch *format {
+	case "markdown", "md":
+		content = bookmarks.Markdown(items)
+	case "json":
+		var err error
+		if content, err = bookmarks.JSON(items); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format %s\n\n%s", *format, bookmarksUsage)
+	}
+
+	if *out == "" {
+		fmt.Print(content)
+		return nil
+	}
+	if err := os.WriteFile(*out, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("error writing %s: %w", *out, err)
+	}
+	fmt.Printf("Exported %d bookmarks to %s\n", len(items), *out)
+	return nil
+}
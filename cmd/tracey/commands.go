@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/keymap"
+	"github.com/zopu/tracey/internal/ui"
+)
+
+// paneKeyMsg runs a key binding of the trace list or details pane from the
+// command palette, focusing the pane first.
+type paneKeyMsg struct {
+	pane int
+	key  tea.KeyMsg
+}
+
+// viewKeyMsg runs a key binding of one of the other views from the command
+// palette, switching to the view first.
+type viewKeyMsg struct {
+	view int
+	key  tea.KeyMsg
+}
+
+// shareKeyMsg runs a key binding of the share menu from the command palette,
+// opening it for the trace being looked at first.
+type shareKeyMsg struct {
+	key tea.KeyMsg
+}
+
+// paletteCommands are the commands in the command palette: those taking an
+// argument, then every action with a key.
+func (m model) paletteCommands() []ui.PaletteCommand {
+	commands := []ui.PaletteCommand{
+		{
+			Name:    "Go to trace",
+			Aliases: []string{"goto", "g"},
+			Arg:     "trace ID, X-Amzn-Trace-Id header or console URL",
+			Run: func(arg string) (tea.Msg, error) {
+				id, err := aws.ParseTraceID(arg)
+				if err != nil {
+					return nil, err
+				}
+				return ui.GotoTraceMsg{ID: id}, nil
+			},
+		},
+		{
+			Name:    "Search logs for traces",
+			Aliases: []string{"logs", "l"},
+			Arg:     "[-<how far back, e.g. -2h>] search term",
+			Run: func(arg string) (tea.Msg, error) {
+				// A leading -<duration> searches that far back rather than
+				// over the trace list's time range
+				var window time.Duration
+				if first, rest, _ := strings.Cut(arg, " "); strings.HasPrefix(first, "-") {
+					if d, err := time.ParseDuration(first[1:]); err == nil && d > 0 {
+						window, arg = d, strings.TrimSpace(rest)
+					}
+				}
+				if arg == "" {
+					return nil, errors.New("logs needs a search term")
+				}
+				return ui.SearchLogsMsg{Term: arg, Window: window}, nil
+			},
+		},
+		{
+			Name:    "Filter traces",
+			Aliases: []string{"filter", "f"},
+			Arg:     "X-Ray filter expression, or nothing to clear it",
+			Run: func(arg string) (tea.Msg, error) {
+				return ui.SetTraceFilterMsg{Filter: arg}, nil
+			},
+		},
+		{
+			Name:    "Time range",
+			Aliases: []string{"range", "r"},
+			Arg:     "how far back to look, e.g. 30m or 12h",
+			Run: func(arg string) (tea.Msg, error) {
+				d, err := time.ParseDuration(arg)
+				if err != nil || d <= 0 {
+					return nil, fmt.Errorf("not a time range: %q", arg)
+				}
+				return ui.SetTraceWindowMsg{Window: d}, nil
+			},
+		},
+		{
+			Name:    "Switch region",
+			Aliases: []string{"region"},
+			Arg:     "region, e.g. eu-west-1",
+			Run: func(arg string) (tea.Msg, error) {
+				region, err := aws.ParseRegion(arg)
+				if err != nil {
+					return nil, err
+				}
+				return ui.SwitchRegionMsg{Region: region}, nil
+			},
+		},
+	}
+
+	add := func(prefix string, bindings []key.Binding, run func(tea.KeyMsg) tea.Msg) {
+		for _, b := range bindings {
+			msg, ok := keymap.KeyMsg(b)
+			if !ok {
+				continue
+			}
+			commands = append(commands, ui.PaletteCommand{
+				Name: prefix + b.Help().Desc,
+				Keys: b.Help().Key,
+				Run: func(string) (tea.Msg, error) {
+					return run(msg), nil
+				},
+			})
+		}
+	}
+	// Global keys are handled by the model, once the palette has closed
+	add("View: ", m.keys.Global.Views(), func(k tea.KeyMsg) tea.Msg { return k })
+	add("", m.keys.Global.Actions(), func(k tea.KeyMsg) tea.Msg { return k })
+	add("List: ", m.keys.List.Actions(), func(k tea.KeyMsg) tea.Msg {
+		return paneKeyMsg{pane: PaneList, key: k}
+	})
+	add("Details: ", m.detailsPane.Actions(), func(k tea.KeyMsg) tea.Msg {
+		return paneKeyMsg{pane: PaneDetails, key: k}
+	})
+	add("Share: ", m.keys.Share.Actions(), func(k tea.KeyMsg) tea.Msg {
+		return shareKeyMsg{key: k}
+	})
+	for _, v := range []struct {
+		prefix   string
+		view     int
+		bindings []key.Binding
+	}{
+		{"Service map: ", ViewServiceMap, m.keys.ServiceMap.Actions()},
+		{"Latency: ", ViewLatency, m.keys.Latency.Actions()},
+		{"Insights: ", ViewInsights, m.keys.Insights.Actions()},
+		{"Sampling: ", ViewSampling, m.keys.Sampling.Actions()},
+		{"Profile: ", ViewProfile, m.keys.Profile.Actions()},
+		{"Bookmarks: ", ViewBookmarks, m.keys.Bookmarks.Actions()},
+	} {
+		add(v.prefix, v.bindings, func(k tea.KeyMsg) tea.Msg {
+			return viewKeyMsg{view: v.view, key: k}
+		})
+	}
+	return commands
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/samber/lo"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/config"
+)
+
+const groupsUsage = `Usage:
+  tracey groups [list]             List X-Ray groups and whether they match the config
+  tracey groups sync [-prune] [-dry-run]
+                                   Create and update groups to match the config
+  tracey groups delete <name>      Delete a group`
+
+// runGroups implements the groups subcommand, which manages X-Ray groups
+// from the definitions in the config file.
+func runGroups(ctx context.Context, cfg config.App, args []string) error {
+	if len(args) == 0 {
+		return listGroups(ctx, cfg)
+	}
+	switch args[0] {
+	case "list":
+		return listGroups(ctx, cfg)
+	case "sync":
+		return syncGroups(ctx, cfg, args[1:])
+	case "delete":
+		if len(args) != 2 {
+			return errors.New(groupsUsage)
+		}
+		if err := aws.DeleteGroup(ctx, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted group %s\n", args[1])
+		return nil
+	}
+	return errors.New(groupsUsage)
+}
+
+func configuredGroups(cfg config.App) []aws.Group {
+	return lo.Map(cfg.Groups, func(g config.Group, _ int) aws.Group {
+		return aws.Group{
+			Name:                 g.Name,
+			FilterExpression:     g.FilterExpression,
+			InsightsEnabled:      g.InsightsEnabled,
+			NotificationsEnabled: g.NotificationsEnabled,
+		}
+	})
+}
+
+func listGroups(ctx context.Context, cfg config.App) error {
+	existing, err := aws.FetchGroups(ctx)
+	if err != nil {
+		return err
+	}
+	changes := aws.PlanGroupChanges(configuredGroups(cfg), existing, true)
+	status := map[string]string{}
+	for _, c := range changes {
+		switch c.Kind {
+		case aws.GroupCreate:
+			status[c.Group.Name] = "missing"
+		case aws.GroupUpdate:
+			status[c.Group.Name] = "differs from config"
+		case aws.GroupDelete:
+			status[c.Group.Name] = "not in config"
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tINSIGHTS\tSTATUS\tFILTER")
+	for _, g := range existing {
+		s, ok := status[g.Name]
+		if !ok {
+			s = "in sync"
+		}
+		if g.Name == aws.DefaultGroup {
+			s = ""
+		}
+		fmt.Fprintf(w, "%s\t%v\t%s\t%s\n", g.Name, g.InsightsEnabled, s, g.FilterExpression)
+	}
+	for _, c := range changes {
+		if c.Kind == aws.GroupCreate {
+			fmt.Fprintf(w, "%s\t%v\t%s\t%s\n", c.Group.Name, c.Group.InsightsEnabled, "missing", c.Group.FilterExpression)
+		}
+	}
+	return w.Flush()
+}
+
+func syncGroups(ctx context.Context, cfg config.App, args []string) error {
+	flags := flag.NewFlagSet("groups sync", flag.ContinueOnError)
+	prune := flags.Bool("prune", false, "delete groups that aren't in the config")
+	dryRun := flags.Bool("dry-run", false, "show the changes without making them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	existing, err := aws.FetchGroups(ctx)
+	if err != nil {
+		return err
+	}
+	changes := aws.PlanGroupChanges(configuredGroups(cfg), existing, *prune)
+	if len(changes) == 0 {
+		fmt.Println("Groups already match the config")
+		return nil
+	}
+
+	for _, c := range changes {
+		switch c.Kind {
+		case aws.GroupCreate:
+			fmt.Printf("+ create %s: %s\n", c.Group.Name, c.Group.FilterExpression)
+		case aws.GroupUpdate:
+			fmt.Printf("~ update %s\n", c.Group.Name)
+			if c.Existing.FilterExpression != c.Group.FilterExpression {
+				fmt.Printf("    filter: %s\n         -> %s\n", c.Existing.FilterExpression, c.Group.FilterExpression)
+			}
+			if c.Existing.InsightsEnabled != c.Group.InsightsEnabled {
+				fmt.Printf("    insights: %v -> %v\n", c.Existing.InsightsEnabled, c.Group.InsightsEnabled)
+			}
+			if c.Existing.NotificationsEnabled != c.Group.NotificationsEnabled {
+				fmt.Printf("    notifications: %v -> %v\n", c.Existing.NotificationsEnabled, c.Group.NotificationsEnabled)
+			}
+		case aws.GroupDelete:
+			fmt.Printf("- delete %s\n", c.Group.Name)
+		}
+	}
+	if *dryRun {
+		return nil
+	}
+
+	for _, c := range changes {
+		switch c.Kind {
+		case aws.GroupCreate:
+			err = aws.CreateGroup(ctx, c.Group)
+		case aws.GroupUpdate:
+			err = aws.UpdateGroup(ctx, c.Group)
+		case aws.GroupDelete:
+			err = aws.DeleteGroup(ctx, c.Group.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Applied %d change(s)\n", len(changes))
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/zopu/tracey/internal/aws"
+	"gopkg.in/yaml.v3"
+)
+
+const samplingUsage = `Usage:
+  tracey sampling [list]           List sampling rules with their recent statistics
+  tracey sampling match [-service name] [-type type] [-host host] [-method method] [-path path]
+                                   Show which rule applies to a request
+  tracey sampling apply [-yes] <file>
+                                   Create or update rules from a YAML or JSON file`
+
+// runSampling implements the sampling subcommand, for checking and editing
+// the sampling rules that decide which requests are traced.
+func runSampling(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return listSamplingRules(ctx)
+	}
+	switch args[0] {
+	case "list":
+		return listSamplingRules(ctx)
+	case "match":
+		return matchSamplingRule(ctx, args[1:])
+	case "apply":
+		return applySamplingRules(ctx, args[1:])
+	}
+	return errors.New(samplingUsage)
+}
+
+func listSamplingRules(ctx context.Context) error {
+	rules, err := aws.FetchSamplingRules(ctx)
+	if err != nil {
+		return err
+	}
+	stats, err := aws.FetchSamplingStatistics(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPRIORITY\tRESERVOIR\tRATE\tSERVICE\tTYPE\tHOST\tMETHOD\tPATH\tREQUESTS\tSAMPLED")
+	for _, r := range rules {
+		s := stats[r.Name]
+		fmt.Fprintf(w, "%s\t%d\t%d/s\t%g%%\t%s\t%s\t%s\t%s\t%s\t%d\t%d\n",
+			r.Name, r.Priority, r.ReservoirSize, r.FixedRate*100,
+			r.ServiceName, r.ServiceType, r.Host, r.HTTPMethod, r.URLPath,
+			s.Requests, s.Sampled)
+	}
+	return w.Flush()
+}
+
+func matchSamplingRule(ctx context.Context, args []string) error {
+	var req aws.SamplingRequest
+	flags := flag.NewFlagSet("sampling match", flag.ContinueOnError)
+	flags.StringVar(&req.ServiceName, "service", "", "service name")
+	flags.StringVar(&req.ServiceType, "type", "", "service type, e.g. AWS::ECS::Container")
+	flags.StringVar(&req.Host, "host", "", "request host")
+	flags.StringVar(&req.HTTPMethod, "method", "", "HTTP method")
+	flags.StringVar(&req.URLPath, "path", "", "URL path")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	rules, err := aws.FetchSamplingRules(ctx)
+	if err != nil {
+		return err
+	}
+	rule, ok := aws.MatchSamplingRule(rules, req).Get()
+	if !ok {
+		fmt.Println("No sampling rule matches")
+		return nil
+	}
+	fmt.Printf("Matches %s (priority %d): the first %d request(s) each second, then %g%% of the rest\n",
+		rule.Name, rule.Priority, rule.ReservoirSize, rule.FixedRate*100)
+	return nil
+}
+
+type samplingRuleFile struct {
+	Rules []aws.SamplingRule `json:"rules" yaml:"rules"`
+}
+
+// parseSamplingRuleFile reads rules from YAML or JSON, depending on the
+// file extension.
+func parseSamplingRuleFile(path string) ([]aws.SamplingRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rule file: %w", err)
+	}
+	var file samplingRuleFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&file)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing rule file: %w", err)
+	}
+
+	rules := make([]aws.SamplingRule, len(file.Rules))
+	for i, r := range file.Rules {
+		switch {
+		case r.Name == "":
+			return nil, fmt.Errorf("error in rule file: rule %d has no name", i+1)
+		case r.FixedRate < 0 || r.FixedRate > 1:
+			return nil, fmt.Errorf("error in rule file: %s has a fixed_rate outside 0-1", r.Name)
+		case r.Name == aws.DefaultSamplingRule:
+			// X-Ray only lets the Default rule's rates change
+			matchFields := r.ServiceName + r.ServiceType + r.Host + r.HTTPMethod + r.URLPath + r.ResourceARN
+			if r.Priority != 0 || matchFields != "" || len(r.Attributes) > 0 {
+				return nil, fmt.Errorf("error in rule file: only fixed_rate and reservoir_size can be set for %s", r.Name)
+			}
+			rules[i] = r
+			continue
+		case r.Priority < 1 || r.Priority > 9999:
+			return nil, fmt.Errorf("error in rule file: %s needs a priority from 1 to 9999", r.Name)
+		}
+		rules[i] = r.WithDefaults()
+	}
+	return rules, nil
+}
+
+func applySamplingRules(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("sampling apply", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "apply without asking for confirmation")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(samplingUsage)
+	}
+	wanted, err := parseSamplingRuleFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	rules, err := aws.FetchSamplingRules(ctx)
+	if err != nil {
+		return err
+	}
+	existing := map[string]aws.SamplingRule{}
+	for _, r := range rules {
+		existing[r.Name] = r
+	}
+
+	creates := make([]aws.SamplingRule, 0)
+	updates := make([]aws.SamplingRule, 0)
+	for _, r := range wanted {
+		old, ok := existing[r.Name]
+		if !ok {
+			creates = append(creates, r)
+			fmt.Printf("+ create %s\n", r.Name)
+			for _, d := range r.Differences(aws.SamplingRule{}) {
+				fmt.Printf("    %s\n", d)
+			}
+			continue
+		}
+		diffs := r.Differences(old)
+		if len(diffs) == 0 {
+			continue
+		}
+		updates = append(updates, r)
+		fmt.Printf("~ update %s\n", r.Name)
+		for _, d := range diffs {
+			fmt.Printf("    %s\n", d)
+		}
+	}
+	if len(creates)+len(updates) == 0 {
+		fmt.Println("Sampling rules already match the file")
+		return nil
+	}
+
+	if !*yes {
+		fmt.Print("Apply these changes? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+	for _, r := range creates {
+		if err = aws.CreateSamplingRule(ctx, r); err != nil {
+			return err
+		}
+	}
+	for _, r := range updates {
+		if err = aws.UpdateSamplingRule(ctx, r); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Applied %d change(s)\n", len(creates)+len(updates))
+	return nil
+}
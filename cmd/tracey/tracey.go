@@ -2,14 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
-	"regexp"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
 	"github.com/samber/mo"
 	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/bookmarks"
 	"github.com/zopu/tracey/internal/config"
+	"github.com/zopu/tracey/internal/keymap"
 	"github.com/zopu/tracey/internal/store"
 	"github.com/zopu/tracey/internal/ui"
 )
@@ -19,50 +27,152 @@ const (
 	PaneDetails
 )
 
+const (
+	ViewTraces = iota
+	ViewServiceMap
+	ViewLatency
+	ViewInsights
+	ViewSampling
+	ViewProfile
+	ViewBookmarks
+)
+
 type Pane interface {
 	SetFocus(bool)
 	Update(tea.Msg) tea.Cmd
+	IsCapturingInput() bool
 }
 
 type model struct {
-	config        config.App
-	logGroups     []string
-	store         *store.Store
-	error         mo.Option[string]
-	list          ui.TraceList
-	detailsPane   ui.DetailsPane
-	helpBar       ui.HelpBar
-	selectedPane  int
-	width, height int
-}
-
-func initialModel(config config.App, logGroups []string) model {
+	config    config.App
+	logGroups []string
+	query     aws.TraceQuery
+	// How far back the query looks when it isn't a fixed time range
+	window         time.Duration
+	store          *store.Store
+	error          mo.Option[string]
+	list           ui.TraceList
+	detailsPane    ui.DetailsPane
+	serviceMap     ui.ServiceMap
+	latencyView    ui.LatencyView
+	insightsView   ui.InsightsView
+	samplingView   ui.SamplingView
+	profileView    ui.ProfileView
+	bookmarksView  ui.BookmarksView
+	groupSelector  ui.GroupSelector
+	bookmarkEditor ui.BookmarkEditor
+	bookmarks      *bookmarks.Bookmarks
+	shareMenu      ui.ShareMenu
+	keys           keymap.KeyMap
+	showHelp       bool
+	palette        ui.CommandPalette
+	region         string
+	helpBar        ui.HelpBar
+	selectedPane   int
+	view           int
+	width, height  int
+	// Traces the configured rules have been run over, or are being
+	checkedTraces map[string]bool
+	// How many traces of the current query have been checked
+	ruleChecks int
+	// Trace to show on launch
+	startTrace mo.Option[aws.TraceID]
+}
+
+func initialModel(config config.App, logGroups []string, bm *bookmarks.Bookmarks, region string, startTrace mo.Option[aws.TraceID]) (model, error) {
+	if err := ui.SetTheme(config.Theme); err != nil {
+		return model{}, err
+	}
+	list, err := ui.NewTraceList(config.TraceList)
+	if err != nil {
+		return model{}, err
+	}
+	keys, err := keymap.New(config.Keys)
+	if err != nil {
+		return model{}, err
+	}
 	st := store.New()
 	m := model{
-		config:    config,
-		logGroups: logGroups,
-		list:      ui.NewTraceList(),
-		detailsPane: ui.DetailsPane{
-			LogFields: config.Logs.ParsedFields,
-		},
-		helpBar:      ui.HelpBar{},
-		selectedPane: PaneList,
-		store:        &st,
+		config:         config,
+		logGroups:      logGroups,
+		list:           list,
+		detailsPane:    ui.NewDetailsPane(config.Logs, config.Rules),
+		serviceMap:     ui.NewServiceMap(),
+		latencyView:    ui.NewLatencyView(),
+		insightsView:   ui.NewInsightsView(),
+		samplingView:   ui.NewSamplingView(),
+		profileView:    ui.NewProfileView(),
+		bookmarksView:  ui.NewBookmarksView(),
+		bookmarkEditor: ui.NewBookmarkEditor(),
+		bookmarks:      bm,
+		shareMenu:      ui.NewShareMenu(),
+		palette:        ui.NewCommandPalette(),
+		keys:           keys,
+		startTrace:     startTrace,
+		region:         region,
+		query:          aws.NewTraceQuery(),
+		window:         aws.DefaultQueryWindow,
+		helpBar:        ui.HelpBar{},
+		selectedPane:   PaneList,
+		store:          &st,
+		checkedTraces:  map[string]bool{},
 	}
 	m.list.SetFocus(true)
-	return m
+	m.list.ShowRules = len(config.Rules) > 0
+	m.list.Keys = keys.List
+	m.detailsPane.Keys = keys.Details
+	m.detailsPane.InspectorKeys = keys.Inspector
+	m.serviceMap.Keys = keys.ServiceMap
+	m.latencyView.Keys = keys.Latency
+	m.insightsView.Keys = keys.Insights
+	m.samplingView.Keys = keys.Sampling
+	m.profileView.Keys = keys.Profile
+	m.bookmarksView.Keys = keys.Bookmarks
+	m.shareMenu.Keys = keys.Share
+	m.palette.Keys = keys.Palette
+	m.bookmarksChanged()
+	return m, nil
 }
 
 func (m model) Init() tea.Cmd {
+	fetchCmd := m.fetchTraceSummaries(mo.None[string]())
+	if id, ok := m.startTrace.Get(); ok {
+		return tea.Batch(fetchCmd, ui.GotoTrace(m.store, id, m.logGroups))
+	}
+	return fetchCmd
+}
+
+func (m model) fetchTraceSummaries(nextToken mo.Option[string]) tea.Cmd {
+	st, query := m.store, m.query
 	return func() tea.Msg {
-		return ui.FetchTraceSummaries(m.store, m.config.ParsedExcludePaths, mo.None[string]())
+		return ui.FetchTraceSummaries(st, query, m.config.ParsedExcludePaths, nextToken)
 	}
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var pane Pane
-	switch m.selectedPane {
-	case PaneDetails:
+	switch {
+	case m.palette.IsOpen():
+		pane = &m.palette
+	case m.groupSelector.IsOpen():
+		pane = &m.groupSelector
+	case m.bookmarkEditor.IsOpen():
+		pane = &m.bookmarkEditor
+	case m.shareMenu.IsOpen():
+		pane = &m.shareMenu
+	case m.view == ViewServiceMap:
+		pane = &m.serviceMap
+	case m.view == ViewLatency:
+		pane = &m.latencyView
+	case m.view == ViewInsights:
+		pane = &m.insightsView
+	case m.view == ViewSampling:
+		pane = &m.samplingView
+	case m.view == ViewProfile:
+		pane = &m.profileView
+	case m.view == ViewBookmarks:
+		pane = &m.bookmarksView
+	case m.selectedPane == PaneDetails:
 		pane = &m.detailsPane
 	default:
 		pane = &m.list
@@ -77,14 +187,104 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ui.ErrorMsg:
 		m.error = mo.Some(msg.Msg)
 
+	case ui.StatusMsg:
+		m.helpBar.Status = msg.Msg
+		// Popups waiting on something that failed stop waiting
+		switch {
+		case m.shareMenu.IsOpen():
+			return m, m.shareMenu.Update(msg)
+		case m.groupSelector.IsOpen():
+			return m, m.groupSelector.Update(msg)
+		}
+
 	case ui.TraceSummaryMsg:
-		m.list.Traces = msg.Traces
+		if msg.Query != m.query {
+			// Results for a query that has since been replaced
+			return m, nil
+		}
+		m.list.SetTraces(msg.Traces)
 		m.list.NextToken = msg.NextToken
+		m.latencyView.SetTraces(m.list.AllTraces())
+		m.updatePaneDimensions()
+		cmds := []tea.Cmd{m.checkRules(msg.Traces)}
 		if msg.ShouldFetchMore {
-			return m, func() tea.Msg {
-				return ui.FetchTraceSummaries(m.store, m.config.ParsedExcludePaths, msg.NextToken)
-			}
+			cmds = append(cmds, m.fetchTraceSummaries(msg.NextToken))
+		}
+		return m, tea.Batch(cmds...)
+
+	case ui.RuleMatchesMsg:
+		m.list.AddRuleMatches(msg.Matches)
+
+	case ui.RuleCheckFailedMsg:
+		// Check them again when they're next loaded
+		for _, id := range msg.IDs {
+			delete(m.checkedTraces, string(id))
+		}
+		m.ruleChecks = max(m.ruleChecks-len(msg.IDs), 0)
+		m.list.RulesStatus = fmt.Sprintf("Rules: couldn't check %d traces, %s", len(msg.IDs), msg.Err)
+
+	case ui.SetTraceFilterMsg:
+		return m, m.setQuery(m.releaseTimeRange().WithFilter(msg.Filter))
+
+	case ui.AddFilterClauseMsg:
+		return m, m.setQuery(m.releaseTimeRange().WithFilterClause(msg.Clause))
+
+	case ui.SetTraceQueryMsg:
+		m.list.TimeRange = fmt.Sprintf("%s – %s",
+			msg.Query.Start.Local().Format("01-02 15:04"), msg.Query.End.Local().Format("01-02 15:04"))
+		return m, m.setQuery(msg.Query)
+
+	case ui.SetTraceWindowMsg:
+		m.window = msg.Window
+		m.list.TimeRange = ""
+		m.list.Window = ""
+		if msg.Window != aws.DefaultQueryWindow {
+			m.list.Window = formatWindow(msg.Window)
 		}
+		return m, m.setQuery(m.query.WithWindow(msg.Window))
+
+	case ui.FilterTracesMsg:
+		m.list.SetLocalFilter(mo.Some(msg.Filter))
+		m.selectView(ViewTraces)
+		return m, nil
+
+	case ui.ServiceGraphMsg:
+		if msg.Query != m.query {
+			// A graph for a query that has since been replaced
+			return m, nil
+		}
+		return m, m.serviceMap.Update(msg)
+
+	case ui.GroupsMsg:
+		return m, m.groupSelector.Update(msg)
+
+	case ui.SelectGroupMsg:
+		m.list.Group = msg.Group.Name
+		return m, m.setQuery(m.releaseTimeRange().WithGroup(msg.Group))
+
+	case ui.SamplingMsg:
+		return m, m.samplingView.Update(msg)
+
+	case ui.InsightsMsg:
+		if msg.Query != m.query {
+			return m, nil
+		}
+		return m, m.insightsView.Update(msg)
+
+	case ui.InsightDetailsMsg:
+		return m, m.insightsView.Update(msg)
+
+	case ui.ProfileTracesMsg:
+		ids := lo.Map(msg.Traces, func(t aws.TraceSummary, _ int) aws.TraceID {
+			return aws.TraceID(t.ID())
+		})
+		ids = ids[:min(len(ids), m.profileTraces())]
+		m.profileView.Start(msg.Description, len(ids))
+		m.selectView(ViewProfile)
+		return m, ui.FetchSpanProfile(msg.Description, ids)
+
+	case ui.SpanProfileMsg:
+		return m, m.profileView.Update(msg)
 
 	case ui.TraceDetailsMsg:
 		return m, m.detailsPane.Update(msg)
@@ -93,7 +293,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.detailsPane.Update(msg)
 
 	case ui.TraceLogsMsg:
-		m.detailsPane.Logs = mo.Some(*msg.Logs)
+		return m, m.detailsPane.Update(msg)
+
+	case ui.LogCopiedMsg:
+		return m, m.detailsPane.Update(msg)
+
+	case ui.ExportedMsg:
+		if m.view == ViewProfile {
+			return m, m.profileView.Update(msg)
+		}
+		return m, m.detailsPane.Update(msg)
 
 	case ui.ListSelectionMsg:
 		clearCmd := func() tea.Msg {
@@ -102,20 +311,198 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		fetchCmd := ui.FetchTraceDetails(msg.ID, m.logGroups)
 		return m, tea.Sequence(clearCmd, fetchCmd)
 
-	case ui.ListAtEndMsg:
-		return m, func() tea.Msg {
-			return ui.FetchTraceSummaries(m.store, m.config.ParsedExcludePaths, m.list.NextToken)
+	case ui.GotoTraceMsg:
+		return m, ui.GotoTrace(m.store, msg.ID, m.logGroups)
+
+	case ui.FoundTraceMsg:
+		id := msg.Summary.ID()
+		m.addTraces([]aws.TraceSummary{msg.Summary})
+		m.list.Select(id)
+		m.selectView(ViewTraces)
+		m.helpBar.Status = msg.LogsErr
+		return m, tea.Batch(m.detailsPane.Update(msg.Details), m.checkRules([]aws.TraceSummary{msg.Summary}))
+
+	case ui.SwitchRegionMsg:
+		aws.SetRegion(msg.Region)
+		m.region = msg.Region
+		// Groups belong to a region, so go back to the default group
+		m.list.Group = ""
+		// and leave out logs until the new region's log groups are found
+		m.logGroups = nil
+		return m, tea.Batch(
+			m.setQuery(m.releaseTimeRange().WithGroup(aws.Group{})),
+			ui.FetchLogGroups(msg.Region, m.config.Logs.Groups))
+
+	case ui.LogGroupsMsg:
+		// Ignore groups for a region that's since been switched away from
+		if msg.Region == m.region {
+			m.logGroups = msg.Groups
+		}
+		return m, nil
+
+	case paneKeyMsg:
+		m.selectView(ViewTraces)
+		if m.selectedPane != msg.pane {
+			m.selectNextPane()
+			m.updatePaneDimensions()
+		}
+		if msg.pane == PaneDetails {
+			return m, m.detailsPane.Update(msg.key)
 		}
+		return m, m.list.Update(msg.key)
+
+	case viewKeyMsg:
+		var cmd tea.Cmd
+		if m.view != msg.view {
+			cmd = m.switchView(msg.view)
+		}
+		return m, tea.Batch(cmd, m.viewPane(msg.view).Update(msg.key))
+
+	case shareKeyMsg:
+		id := m.detailsPane.TraceID()
+		if m.view != ViewTraces || m.selectedPane != PaneDetails || id.IsAbsent() {
+			id = mo.None[aws.TraceID]()
+			if trace, ok := m.list.HighlightedTrace().Get(); ok {
+				id = mo.Some(aws.TraceID(trace.ID()))
+			}
+		}
+		if id.IsAbsent() {
+			m.helpBar.Status = "no trace to share"
+			return m, nil
+		}
+		m.shareMenu.Open(m.traceLinks(id.MustGet()))
+		return m, m.shareMenu.Update(msg.key)
+
+	case ui.SearchLogsMsg:
+		if len(m.logGroups) == 0 {
+			m.helpBar.Status = "no log groups to search, add some to logs.groups in the config"
+			return m, nil
+		}
+		start, end := m.query.Start, m.query.End
+		if msg.Window > 0 {
+			end = time.Now()
+			start = end.Add(-msg.Window)
+		}
+		return m, ui.SearchLogsForTraces(m.store, m.logGroups, msg.Term, start, end, m.config.Logs)
+
+	case ui.LogSearchMsg:
+		m.addTraces(msg.Traces)
+		found := lo.SliceToMap(msg.Traces, func(t aws.TraceSummary) (string, bool) { return t.ID(), true })
+		m.list.SetLocalFilter(mo.Some(ui.TraceFilter{
+			Description: fmt.Sprintf("%d traces in %d log events containing %q", len(found), msg.Events, msg.Term),
+			Match: func(t aws.TraceSummary) bool {
+				return found[t.ID()]
+			},
+		}))
+		m.selectView(ViewTraces)
+		return m, m.checkRules(msg.Traces)
+
+	case ui.ShareTraceMsg:
+		m.shareMenu.Open(m.traceLinks(msg.ID))
+		return m, nil
+
+	case ui.OpenTraceMsg:
+		return m, ui.OpenURL(aws.TraceConsoleURL(m.region, msg.ID))
+
+	case ui.CopiedMsg:
+		return m, m.shareMenu.Update(msg)
+
+	case ui.EditBookmarkMsg:
+		bm, ok := m.bookmarks.Get(msg.ID).Get()
+		if !ok {
+			bm = bookmarks.Bookmark{TraceID: msg.ID, Added: time.Now()}
+			if trace, found := lo.Find(m.list.AllTraces(), func(t aws.TraceSummary) bool {
+				return t.ID() == msg.ID
+			}); found {
+				bm = bookmarks.New(trace)
+			}
+		}
+		m.bookmarkEditor.Open(bm)
+		return m, nil
+
+	case ui.SaveBookmarkMsg:
+		m.bookmarks.Set(msg.Bookmark)
+		m.bookmarksChanged()
+		return m, ui.SaveBookmarks(*m.bookmarks)
+
+	case ui.DeleteBookmarkMsg:
+		m.bookmarks.Remove(msg.ID)
+		m.bookmarksChanged()
+		return m, ui.SaveBookmarks(*m.bookmarks)
+
+	case ui.CompareTracesMsg:
+		clearCmd := func() tea.Msg {
+			return ui.ClearTraceDetailsMsg{}
+		}
+		return m, tea.Sequence(clearCmd, ui.FetchTraceComparison(msg.Baseline, msg.Other))
+
+	case ui.TraceComparisonMsg:
+		return m, m.detailsPane.Update(msg)
+
+	case ui.BaselineFailedMsg:
+		m.list.ClearBaseline(string(msg.Baseline))
+		m.helpBar.Status = msg.Status.Msg
+		return m, nil
+
+	case ui.ListAtEndMsg:
+		return m, m.fetchTraceSummaries(m.list.NextToken)
 	case ui.SelectNextPaneMsg:
 		m.selectNextPane()
 		m.updatePaneDimensions()
 		return m, nil
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		m.helpBar.Status = ""
+		if pane.IsCapturingInput() && msg.String() != "ctrl+c" {
+			return m, pane.Update(msg)
+		}
+		if m.showHelp {
+			// Only close the help, so keys don't act on the pane behind it
+			if key.Matches(msg, m.keys.Global.Quit) {
+				return m, tea.Quit
+			}
+			if key.Matches(msg, m.keys.Global.Help, m.keys.Details.Back) {
+				m.showHelp = false
+			}
+			return m, nil
+		}
+		keys := m.keys.Global
+		switch {
+		case key.Matches(msg, keys.Quit):
 			return m, tea.Quit
 
+		case key.Matches(msg, keys.Help):
+			m.showHelp = true
+			return m, nil
+
+		case key.Matches(msg, keys.Traces):
+			return m, m.switchView(ViewTraces)
+
+		case key.Matches(msg, keys.ServiceMap):
+			return m, m.switchView(ViewServiceMap)
+
+		case key.Matches(msg, keys.Latency):
+			return m, m.switchView(ViewLatency)
+
+		case key.Matches(msg, keys.Insights):
+			return m, m.switchView(ViewInsights)
+
+		case key.Matches(msg, keys.Sampling):
+			return m, m.switchView(ViewSampling)
+
+		case key.Matches(msg, keys.Profile):
+			return m, m.switchView(ViewProfile)
+
+		case key.Matches(msg, keys.Bookmarks):
+			return m, m.switchView(ViewBookmarks)
+
+		case key.Matches(msg, keys.Group):
+			return m, m.groupSelector.Open(m.query.Group)
+
+		case key.Matches(msg, keys.Command):
+			m.palette.Open(m.paletteCommands())
+			return m, nil
+
 		default:
 			cmd := pane.Update(msg)
 			return m, cmd
@@ -124,6 +511,232 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// helpBindings are the keys shown in the help bar: the active pane's, then
+// the global ones. Popups take every key, so only theirs are shown.
+func (m model) helpBindings() []key.Binding {
+	if m.palette.IsOpen() {
+		return m.keys.Palette.ShortHelp()
+	}
+	if m.shareMenu.IsOpen() {
+		return m.keys.Share.ShortHelp()
+	}
+	var bindings []key.Binding
+	switch m.view {
+	case ViewTraces:
+		if m.selectedPane == PaneDetails && m.detailsPane.IsInspecting() {
+			bindings = append(m.keys.Inspector.ShortHelp(), m.keys.Details.Back)
+		} else if m.selectedPane == PaneDetails {
+			bindings = m.keys.Details.ShortHelp()
+		} else {
+			bindings = m.keys.List.ShortHelp()
+		}
+	case ViewServiceMap:
+		bindings = m.keys.ServiceMap.ShortHelp()
+	case ViewLatency:
+		bindings = m.keys.Latency.ShortHelp()
+	case ViewInsights:
+		bindings = m.keys.Insights.ShortHelp()
+	case ViewSampling:
+		bindings = m.keys.Sampling.ShortHelp()
+	case ViewProfile:
+		bindings = m.keys.Profile.ShortHelp()
+	case ViewBookmarks:
+		bindings = m.keys.Bookmarks.ShortHelp()
+	}
+	bindings = append(bindings, m.keys.Global.ShortHelp()...)
+	return append(bindings, m.keys.Global.Help)
+}
+
+// helpSections lists every key for the active pane and the global ones.
+func (m model) helpSections() []ui.HelpSection {
+	var section ui.HelpSection
+	switch m.view {
+	case ViewTraces:
+		if m.selectedPane == PaneDetails {
+			section = ui.HelpSection{Title: "Trace details", Columns: m.keys.Details.FullHelp()}
+		} else {
+			section = ui.HelpSection{Title: "Trace list", Columns: m.keys.List.FullHelp()}
+		}
+	case ViewServiceMap:
+		section = ui.HelpSection{Title: "Service map", Columns: m.keys.ServiceMap.FullHelp()}
+	case ViewLatency:
+		section = ui.HelpSection{Title: "Latency", Columns: m.keys.Latency.FullHelp()}
+	case ViewInsights:
+		section = ui.HelpSection{Title: "Insights", Columns: m.keys.Insights.FullHelp()}
+	case ViewSampling:
+		section = ui.HelpSection{Title: "Sampling", Columns: m.keys.Sampling.FullHelp()}
+	case ViewProfile:
+		section = ui.HelpSection{Title: "Profile", Columns: m.keys.Profile.FullHelp()}
+	case ViewBookmarks:
+		section = ui.HelpSection{Title: "Bookmarks", Columns: m.keys.Bookmarks.FullHelp()}
+	}
+	sections := []ui.HelpSection{section}
+	if m.view == ViewTraces && m.selectedPane == PaneDetails && m.detailsPane.IsInspecting() {
+		sections = append(sections, ui.HelpSection{Title: "Span inspector", Columns: m.keys.Inspector.FullHelp()})
+	}
+	return append(sections, ui.HelpSection{Title: "Global", Columns: m.keys.Global.FullHelp()})
+}
+
+// addTraces adds traces fetched by ID to the list, for traces that may
+// not be in the current query's results.
+func (m *model) addTraces(traces []aws.TraceSummary) {
+	listed := lo.SliceToMap(m.list.AllTraces(), func(t aws.TraceSummary) (string, bool) { return t.ID(), true })
+	added := lo.Filter(traces, func(t aws.TraceSummary, _ int) bool { return !listed[t.ID()] })
+	if len(added) == 0 {
+		return
+	}
+	m.list.SetTraces(append(m.list.AllTraces(), added...))
+	m.latencyView.SetTraces(m.list.AllTraces())
+	m.updatePaneDimensions()
+}
+
+// traceLinks links to a trace in the console. The Logs Insights query
+// covers a few minutes either side of the trace, when it's in the list.
+func (m model) traceLinks(id aws.TraceID) ui.TraceLinks {
+	end := time.Now()
+	start := end.Add(-24 * time.Hour)
+	if trace, found := lo.Find(m.list.AllTraces(), func(t aws.TraceSummary) bool {
+		return t.ID() == string(id)
+	}); found {
+		start = trace.StartTime().Add(-5 * time.Minute)
+		end = trace.StartTime().Add(trace.Duration() + 5*time.Minute)
+	}
+	return ui.TraceLinks{
+		ID:          id,
+		ConsoleURL:  aws.TraceConsoleURL(m.region, id),
+		LogsURL:     aws.LogsInsightsConsoleURL(m.region, m.logGroups, aws.LogsQuery(id), start, end),
+		LogsEnabled: len(m.logGroups) > 0,
+	}
+}
+
+// bookmarksChanged updates the views that show bookmarks.
+func (m *model) bookmarksChanged() {
+	m.list.Bookmarked = m.bookmarks.IDs()
+	m.bookmarksView.SetBookmarks(m.bookmarks.Items)
+}
+
+// checkRules runs the configured rules over traces that haven't been
+// checked yet, up to the most traces to check for each query.
+func (m *model) checkRules(traces []aws.TraceSummary) tea.Cmd {
+	if len(m.config.Rules) == 0 {
+		return nil
+	}
+	limit := ui.DefaultRuleTraces
+	if m.config.RuleTraces > 0 {
+		limit = m.config.RuleTraces
+	}
+	ids := make([]aws.TraceID, 0)
+	for _, t := range traces {
+		if m.checkedTraces[t.ID()] {
+			continue
+		}
+		if m.ruleChecks >= limit {
+			m.list.RulesStatus = fmt.Sprintf("Rules: only the first %d traces were checked", limit)
+			break
+		}
+		m.checkedTraces[t.ID()] = true
+		m.ruleChecks++
+		ids = append(ids, aws.TraceID(t.ID()))
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return ui.CheckTraceRules(m.config.Rules, ids)
+}
+
+// releaseTimeRange is the query to change when filtering or switching
+// group. A fixed time range, e.g. an insight's, goes back to the window
+// picked last, since the list stops showing it.
+func (m *model) releaseTimeRange() aws.TraceQuery {
+	if m.list.TimeRange == "" {
+		return m.query
+	}
+	m.list.TimeRange = ""
+	return m.query.WithWindow(m.window)
+}
+
+// formatWindow leaves out zero minutes and seconds, e.g. "2h" not "2h0m0s".
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// setQuery starts fetching traces for a new query, in a new store so that
+// results still arriving for the old query are kept apart.
+func (m *model) setQuery(query aws.TraceQuery) tea.Cmd {
+	m.query = query
+	m.ruleChecks = 0
+	m.list.RulesStatus = ""
+	st := store.New()
+	m.store = &st
+	m.list.Filter = query.Filter
+	m.list.SetTraces([]aws.TraceSummary{})
+	m.list.NextToken = mo.None[string]()
+	m.selectView(ViewTraces)
+	return m.fetchTraceSummaries(mo.None[string]())
+}
+
+// profileTraces is the most traces to fetch for a span profile.
+func (m model) profileTraces() int {
+	if m.config.ProfileTraces > 0 {
+		return m.config.ProfileTraces
+	}
+	return ui.DefaultProfileTraces
+}
+
+// switchView shows a view, loading what it shows.
+func (m *model) switchView(view int) tea.Cmd {
+	switch view {
+	case ViewServiceMap:
+		m.selectView(view)
+		return ui.FetchServiceGraph(m.query)
+	case ViewLatency:
+		m.latencyView.SetTraces(m.list.AllTraces())
+	case ViewInsights:
+		m.selectView(view)
+		return ui.FetchInsights(m.query)
+	case ViewSampling:
+		req := aws.SamplingRequest{}
+		if trace, ok := m.list.HighlightedTrace().Get(); ok {
+			req = aws.SamplingRequestFor(trace)
+		}
+		m.samplingView.SetRequest(req)
+		m.selectView(view)
+		return ui.FetchSampling()
+	}
+	m.selectView(view)
+	return nil
+}
+
+// viewPane is the pane that fills a view other than the trace list.
+func (m *model) viewPane(view int) Pane {
+	switch view {
+	case ViewServiceMap:
+		return &m.serviceMap
+	case ViewLatency:
+		return &m.latencyView
+	case ViewInsights:
+		return &m.insightsView
+	case ViewSampling:
+		return &m.samplingView
+	case ViewProfile:
+		return &m.profileView
+	default:
+		return &m.bookmarksView
+	}
+}
+
+func (m *model) selectView(view int) {
+	m.view = view
+	m.updatePaneDimensions()
+}
+
 func (m *model) selectNextPane() {
 	switch m.selectedPane {
 	case PaneList:
@@ -139,8 +752,20 @@ func (m *model) selectNextPane() {
 
 func (m *model) updatePaneDimensions() {
 	m.list.Width = m.width
-	m.detailsPane.Width = m.width
 	m.helpBar.Width = m.width
+	// The details pane gets whatever height the list and help bar leave over
+	detailsHeight := m.height - lipgloss.Height(m.list.View()) - lipgloss.Height(m.helpBar.Render())
+	m.detailsPane.SetSize(m.width, max(detailsHeight, 0))
+	fullHeight := max(m.height-lipgloss.Height(m.helpBar.Render()), 0)
+	m.serviceMap.SetSize(m.width, fullHeight)
+	m.latencyView.SetSize(m.width, fullHeight)
+	m.insightsView.SetSize(m.width, fullHeight)
+	m.samplingView.SetSize(m.width, fullHeight)
+	m.profileView.SetSize(m.width, fullHeight)
+	m.groupSelector.SetSize(m.width, fullHeight)
+	m.bookmarkEditor.SetSize(m.width, fullHeight)
+	m.bookmarksView.SetSize(m.width, fullHeight)
+	m.palette.SetSize(m.width, fullHeight)
 }
 
 func (m model) View() string {
@@ -148,40 +773,128 @@ func (m model) View() string {
 		return "Error: " + m.error.MustGet() + "\n\n"
 	}
 
-	list := m.list.View()
+	m.helpBar.Bindings = m.helpBindings()
 	helpBar := m.helpBar.Render()
+	fullScreen := lipgloss.NewStyle().
+		Width(m.width).
+		Height(m.height - lipgloss.Height(helpBar)).
+		MaxHeight(m.height - lipgloss.Height(helpBar))
+	if m.palette.IsOpen() {
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(m.palette.View()), helpBar)
+	}
+	if m.groupSelector.IsOpen() {
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(m.groupSelector.View()), helpBar)
+	}
+	if m.bookmarkEditor.IsOpen() {
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(m.bookmarkEditor.View()), helpBar)
+	}
+	if m.shareMenu.IsOpen() {
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(m.shareMenu.View()), helpBar)
+	}
+	if m.showHelp {
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(ui.RenderFullHelp(m.helpSections())), helpBar)
+	}
+	switch m.view {
+	case ViewServiceMap:
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(m.serviceMap.View()), helpBar)
+	case ViewLatency:
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(m.latencyView.View()), helpBar)
+	case ViewInsights:
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(m.insightsView.View()), helpBar)
+	case ViewSampling:
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(m.samplingView.View()), helpBar)
+	case ViewProfile:
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(m.profileView.View()), helpBar)
+	case ViewBookmarks:
+		return lipgloss.JoinVertical(lipgloss.Top, fullScreen.Render(m.bookmarksView.View()), helpBar)
+	}
+
+	list := m.list.View()
 	main := lipgloss.NewStyle().
 		Width(m.width).
 		Height(m.height - lipgloss.Height(list) - lipgloss.Height(helpBar)).
+		MaxHeight(m.height - lipgloss.Height(list) - lipgloss.Height(helpBar)).
 		Render(m.detailsPane.View())
 
 	return lipgloss.JoinVertical(lipgloss.Top, list, main, helpBar)
 }
 
+const traceUsage = `Usage:
+  tracey <trace-id>                Open a trace by ID, X-Amzn-Trace-Id header or console URL
+  tracey --trace <trace-id>`
+
+// parseTraceArgs finds a trace to open on launch, given as the only
+// argument or with --trace.
+func parseTraceArgs(args []string) (aws.TraceID, bool, error) {
+	if len(args) == 0 {
+		return "", false, nil
+	}
+	if value, ok := strings.CutPrefix(args[0], "--trace="); ok {
+		id, err := aws.ParseTraceID(value)
+		return id, true, err
+	}
+	if args[0] == "--trace" || args[0] == "-trace" {
+		if len(args) < 2 {
+			return "", true, errors.New(traceUsage)
+		}
+		id, err := aws.ParseTraceID(args[1])
+		return id, true, err
+	}
+	if id, err := aws.ParseTraceID(args[0]); err == nil && len(args) == 1 {
+		return id, true, nil
+	}
+	return "", false, nil
+}
+
 func main() {
 	config, err := config.Parse()
 	if err != nil {
 		log.Fatalf("Error loading config: %s", err)
 	}
 
-	logGroups, err := aws.GetLogGroups(context.Background())
-	if err != nil {
-		log.Fatalf("Could not load log groups")
-	}
-	filteredLogGroups := make([]string, 0)
-	for _, groupFilter := range config.Logs.Groups {
-		re, reErr := regexp.Compile(groupFilter)
-		if reErr != nil {
-			log.Fatalf("Could not compile log group regexp: %s", reErr)
+	startTrace := mo.None[aws.TraceID]()
+	if id, ok, traceErr := parseTraceArgs(os.Args[1:]); traceErr != nil {
+		fmt.Fprintln(os.Stderr, traceErr)
+		os.Exit(1)
+	} else if ok {
+		startTrace = mo.Some(id)
+	} else if len(os.Args) > 1 {
+		var cmdErr error
+		switch os.Args[1] {
+		case "groups":
+			cmdErr = runGroups(context.Background(), *config, os.Args[2:])
+		case "sampling":
+			cmdErr = runSampling(context.Background(), os.Args[2:])
+		case "bookmarks":
+			cmdErr = runBookmarks(*config, os.Args[2:])
+		default:
+			cmdErr = fmt.Errorf("unknown command %s\n\n%s\n%s\n%s\n%s", os.Args[1], traceUsage, groupsUsage, samplingUsage, bookmarksUsage)
 		}
-		for _, lg := range logGroups {
-			if re.MatchString(lg) {
-				filteredLogGroups = append(filteredLogGroups, lg)
-			}
+		if cmdErr != nil {
+			fmt.Fprintln(os.Stderr, cmdErr)
+			os.Exit(1)
 		}
+		return
+	}
+
+	logGroups, err := aws.MatchingLogGroups(context.Background(), config.Logs.Groups)
+	if err != nil {
+		log.Fatalf("Could not load log groups: %s", err)
 	}
 
-	p := tea.NewProgram(initialModel(*config, filteredLogGroups), tea.WithAltScreen())
+	bm, err := loadBookmarks(*config)
+	if err != nil {
+		log.Fatalf("Error loading bookmarks: %s", err)
+	}
+	region, err := aws.Region(context.Background())
+	if err != nil {
+		log.Fatalf("Error loading AWS configuration: %s", err)
+	}
+	m, err := initialModel(*config, logGroups, bm, region, startTrace)
+	if err != nil {
+		log.Fatalf("Error in config: %s", err)
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err = p.Run(); err != nil {
 		log.Fatalf("Alas, there's been an error: %v", err)
 	}
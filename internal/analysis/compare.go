@@ -0,0 +1,145 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/aws"
+)
+
+// SpanDiff lines up a span in a baseline trace with the same span in another
+// trace. Either side is missing when the span only appears in one of them.
+type SpanDiff struct {
+	Name     string
+	Depth    int
+	Baseline mo.Option[SpanNode]
+	Other    mo.Option[SpanNode]
+	// Differences other than timing, such as SQL or annotations
+	Changes []string
+}
+
+// Delta is how much longer the span took in the other trace.
+func (d SpanDiff) Delta() mo.Option[time.Duration] {
+	b, okB := d.Baseline.Get()
+	o, okO := d.Other.Get()
+	if !okB || !okO {
+		return mo.None[time.Duration]()
+	}
+	return mo.Some(o.Duration - b.Duration)
+}
+
+// CompareTraces aligns the span trees of two traces. Spans are matched by
+// their name and position among same-named siblings, so the second DynamoDB
+// call under a span is compared with the second DynamoDB call in the other
+// trace. Diffs are in tree order, depth first.
+func CompareTraces(baseline, other aws.TraceDetails) []SpanDiff {
+	diffs := make([]SpanDiff, 0)
+	compareSpans(SpanTree(baseline), SpanTree(other), 0, &diffs)
+	return diffs
+}
+
+// spanKeys names each span by its name and how many same-named spans came
+// before it.
+func spanKeys(nodes []SpanNode) []string {
+	seen := map[string]int{}
+	return lo.Map(nodes, func(n SpanNode, _ int) string {
+		seen[n.Name]++
+		return fmt.Sprintf("%s#%d", n.Name, seen[n.Name])
+	})
+}
+
+func compareSpans(baseline, other []SpanNode, depth int, diffs *[]SpanDiff) {
+	baselineKeys := spanKeys(baseline)
+	otherKeys := spanKeys(other)
+
+	onlyInOther := func(n SpanNode) {
+		*diffs = append(*diffs, SpanDiff{Name: n.Name, Depth: depth, Other: mo.Some(n)})
+		compareSpans(nil, n.Children, depth+1, diffs)
+	}
+
+	// Walk the baseline's spans in order, slotting in the other trace's
+	// unmatched spans where they appear relative to the matched ones
+	next := 0
+	for i, b := range baseline {
+		k := slices.Index(otherKeys, baselineKeys[i])
+		if k < 0 {
+			*diffs = append(*diffs, SpanDiff{Name: b.Name, Depth: depth, Baseline: mo.Some(b)})
+			compareSpans(b.Children, nil, depth+1, diffs)
+			continue
+		}
+		for ; next < k; next++ {
+			if !slices.Contains(baselineKeys, otherKeys[next]) {
+				onlyInOther(other[next])
+			}
+		}
+		next = max(next, k+1)
+		o := other[k]
+		*diffs = append(*diffs, SpanDiff{
+			Name:     b.Name,
+			Depth:    depth,
+			Baseline: mo.Some(b),
+			Other:    mo.Some(o),
+			Changes:  spanChanges(b, o),
+		})
+		compareSpans(b.Children, o.Children, depth+1, diffs)
+	}
+	for ; next < len(other); next++ {
+		if !slices.Contains(baselineKeys, otherKeys[next]) {
+			onlyInOther(other[next])
+		}
+	}
+}
+
+func spanChanges(baseline, other SpanNode) []string {
+	changes := make([]string, 0)
+	flag := func(name string, b, o bool) {
+		if b != o {
+			changes = append(changes, fmt.Sprintf("%s: %v → %v", name, b, o))
+		}
+	}
+	flag("fault", baseline.Fault, other.Fault)
+	flag("error", baseline.Error, other.Error)
+	flag("throttle", baseline.Throttle, other.Throttle)
+
+	bSQL := NormalizeWhitespace(baseline.SQL.OrEmpty().SanitizedQuery)
+	oSQL := NormalizeWhitespace(other.SQL.OrEmpty().SanitizedQuery)
+	if bSQL != oSQL {
+		changes = append(changes, fmt.Sprintf("SQL: %.80s → %.80s", orNone(bSQL), orNone(oSQL)))
+	}
+
+	keys := lo.Uniq(append(lo.Keys(baseline.Annotations), lo.Keys(other.Annotations)...))
+	slices.Sort(keys)
+	for _, k := range keys {
+		b, okB := baseline.Annotations[k]
+		o, okO := other.Annotations[k]
+		if okB != okO || fmt.Sprint(b) != fmt.Sprint(o) {
+			changes = append(changes, fmt.Sprintf("annotation %s: %s → %s",
+				k, orNone(annotationText(b, okB)), orNone(annotationText(o, okO))))
+		}
+	}
+	return changes
+}
+
+func annotationText(v any, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// NormalizeWhitespace collapses runs of whitespace, e.g. in SQL, to single
+// spaces.
+func NormalizeWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
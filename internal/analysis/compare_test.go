@@ -0,0 +1,61 @@
+package analysis_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zopu/tracey/internal/analysis"
+)
+
+const (
+	slowAPISegment = `{
+		"name": "api", "id": "a1", "origin": "AWS::ECS::Container",
+		"start_time": 200.0, "end_time": 201.5,
+		"subsegments": [
+			{"name": "DynamoDB", "id": "s1", "namespace": "aws", "start_time": 200.1, "end_time": 200.2},
+			{"name": "users", "id": "s3", "namespace": "remote", "start_time": 200.2, "end_time": 201.2},
+			{"name": "audit", "id": "s5", "namespace": "remote", "start_time": 201.2, "end_time": 201.4}
+		]
+	}`
+	slowUsersSegment = `{
+		"name": "users", "id": "u1", "parent_id": "s3",
+		"start_time": 200.25, "end_time": 201.15,
+		"annotations": {"cache": "miss"},
+		"subsegments": [
+			{"name": "db", "id": "s4", "start_time": 200.3, "end_time": 201.1,
+			 "sql": {"url": "postgres://db.internal:5432/users", "sanitized_query": "SELECT * FROM users"}}
+		]
+	}`
+)
+
+func TestCompareTraces(t *testing.T) {
+	baseline := parseSegments(t, apiSegment, usersSegment)
+	other := parseSegments(t, slowAPISegment, slowUsersSegment)
+	diffs := analysis.CompareTraces(baseline, other)
+
+	names := make([]string, len(diffs))
+	for i, d := range diffs {
+		names[i] = strings.Repeat(" ", d.Depth) + d.Name
+	}
+	expected := []string{"api", " DynamoDB", " DynamoDB", " users", "  users", "   db", " audit"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected spans %q, got %q", expected, names)
+	}
+
+	if diffs[2].Other.IsPresent() || !diffs[2].Baseline.IsPresent() {
+		t.Errorf("Expected the second DynamoDB call to be only in the baseline, got %+v", diffs[2])
+	}
+	if diffs[6].Baseline.IsPresent() {
+		t.Errorf("Expected the audit call to be only in the other trace, got %+v", diffs[6])
+	}
+	if delta := diffs[5].Delta().MustGet(); delta.Round(time.Millisecond) != 700*time.Millisecond {
+		t.Errorf("Expected db to be 700ms slower, got %s", delta)
+	}
+	if len(diffs[4].Changes) != 1 || diffs[4].Changes[0] != "annotation cache: (none) → miss" {
+		t.Errorf("Expected the cache annotation to differ, got %q", diffs[4].Changes)
+	}
+	if len(diffs[5].Changes) != 1 || !strings.HasPrefix(diffs[5].Changes[0], "SQL: (none) → SELECT") {
+		t.Errorf("Expected the SQL to differ, got %q", diffs[5].Changes)
+	}
+}
@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"slices"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+// CriticalSpan is a span on a trace's critical path: the chain of spans that
+// the trace's duration depends on. Speeding up anything else wouldn't make
+// the trace finish sooner.
+type CriticalSpan struct {
+	Span  SpanNode
+	Depth int
+	// Time on the path spent in the span itself, including gaps between its
+	// children
+	Self time.Duration
+	// Time on the path spent waiting for the span's children
+	Waiting time.Duration
+}
+
+// CriticalPath finds the critical path through a trace, in the order the
+// spans start, with each span followed by the children it waited on. It
+// starts from the root span that finishes last, and works back from the end
+// of each span through the child that finished last before then, so of
+// several children running in parallel only the slowest is on the path.
+func CriticalPath(td aws.TraceDetails) []CriticalSpan {
+	roots := SpanTree(td)
+	if len(roots) == 0 {
+		return []CriticalSpan{}
+	}
+	root := roots[0]
+	for _, r := range roots[1:] {
+		if r.Offset+r.Duration > root.Offset+root.Duration {
+			root = r
+		}
+	}
+	path := make([]CriticalSpan, 0)
+	appendCriticalPath(&path, root, 0, root.Offset, root.Offset+root.Duration)
+	return path
+}
+
+// appendCriticalPath adds a span that's on the path between start and end,
+// followed by the children on the path.
+func appendCriticalPath(path *[]CriticalSpan, n SpanNode, depth int, start, end time.Duration) {
+	type window struct {
+		child      SpanNode
+		start, end time.Duration
+	}
+	// Walk back from the end, each time picking the child that finished
+	// last before the point reached so far. Children can end after their
+	// parent because of clock skew, so their ends are clipped.
+	onPath := make([]window, 0)
+	cursor := end
+	remaining := slices.Clone(n.Children)
+	for cursor > start {
+		best := -1
+		var bestEnd time.Duration
+		for i, c := range remaining {
+			if c.Offset >= cursor || c.Offset+c.Duration <= start {
+				continue
+			}
+			e := min(c.Offset+c.Duration, cursor)
+			if best < 0 || e > bestEnd || (e == bestEnd && c.Offset > remaining[best].Offset) {
+				best, bestEnd = i, e
+			}
+		}
+		if best < 0 {
+			break
+		}
+		c := remaining[best]
+		w := window{child: c, start: max(c.Offset, start), end: bestEnd}
+		onPath = append(onPath, w)
+		remaining = slices.Delete(remaining, best, best+1)
+		cursor = w.start
+	}
+
+	var waiting time.Duration
+	for _, w := range onPath {
+		waiting += w.end - w.start
+	}
+	*path = append(*path, CriticalSpan{Span: n, Depth: depth, Self: end - start - waiting, Waiting: waiting})
+	for i := len(onPath) - 1; i >= 0; i-- {
+		w := onPath[i]
+		appendCriticalPath(path, w.child, depth+1, w.start, w.end)
+	}
+}
@@ -0,0 +1,70 @@
+package analysis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zopu/tracey/internal/analysis"
+)
+
+const parallelSegment = `{
+	"name": "api", "id": "a1",
+	"start_time": 100.0, "end_time": 101.0,
+	"subsegments": [
+		{"name": "auth", "id": "s1", "start_time": 100.0, "end_time": 100.1},
+		{"name": "orders", "id": "s2", "start_time": 100.2, "end_time": 100.8},
+		{"name": "stock", "id": "s3", "start_time": 100.2, "end_time": 100.5},
+		{"name": "prices", "id": "s4", "start_time": 100.3, "end_time": 100.6}
+	]
+}`
+
+func TestCriticalPath(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name     string
+		segments []string
+		names    []string
+		want     []analysis.CriticalSpan
+	}{
+		{
+			name:     "sequential calls and a downstream service",
+			segments: []string{apiSegment, usersSegment},
+			names:    []string{"api", "DynamoDB", "DynamoDB", "users", "users", "db"},
+			want: []analysis.CriticalSpan{
+				{Depth: 0, Self: 200 * ms, Waiting: 800 * ms},
+				{Depth: 1, Self: 100 * ms},
+				{Depth: 1, Self: 200 * ms},
+				{Depth: 1, Self: 100 * ms, Waiting: 400 * ms},
+				{Depth: 2, Self: 300 * ms, Waiting: 100 * ms},
+				{Depth: 3, Self: 100 * ms},
+			},
+		},
+		{
+			name:     "parallel calls",
+			segments: []string{parallelSegment},
+			names:    []string{"api", "auth", "orders"},
+			want: []analysis.CriticalSpan{
+				{Depth: 0, Self: 300 * ms, Waiting: 700 * ms},
+				{Depth: 1, Self: 100 * ms},
+				{Depth: 1, Self: 600 * ms},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := analysis.CriticalPath(parseSegments(t, tt.segments...))
+			if len(path) != len(tt.want) {
+				t.Fatalf("expected %d spans on the path, got %d", len(tt.want), len(path))
+			}
+			for i, want := range tt.want {
+				got := path[i]
+				if got.Span.Name != tt.names[i] || got.Depth != want.Depth ||
+					got.Self.Round(ms) != want.Self || got.Waiting.Round(ms) != want.Waiting {
+					t.Errorf("span %d: expected %s at depth %d with %s self and %s waiting, got %s at %d with %s and %s",
+						i, tt.names[i], want.Depth, want.Self, want.Waiting,
+						got.Span.Name, got.Depth, got.Self, got.Waiting)
+				}
+			}
+		})
+	}
+}
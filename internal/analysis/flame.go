@@ -0,0 +1,107 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+// FlameNode is a frame of a flame graph. Spans with the same name under the
+// same parent frame are merged, across traces when several are given.
+type FlameNode struct {
+	Name string
+	// Time in the spans not covered by any of their children
+	Self time.Duration
+	// Self time plus the children's totals. Children running in parallel
+	// can make this more than the spans' duration.
+	Total    time.Duration
+	Children []FlameNode
+}
+
+// SelfTime is the part of a span's duration when none of its children were
+// running.
+func SelfTime(n SpanNode) time.Duration {
+	start, end := n.Offset, n.Offset+n.Duration
+	type interval struct{ start, end time.Duration }
+	children := make([]interval, 0, len(n.Children))
+	for _, c := range n.Children {
+		s, e := max(c.Offset, start), min(c.Offset+c.Duration, end)
+		if e > s {
+			children = append(children, interval{s, e})
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].start < children[j].start })
+
+	var covered time.Duration
+	coveredTo := start
+	for _, c := range children {
+		if c.end <= coveredTo {
+			continue
+		}
+		covered += c.end - max(c.start, coveredTo)
+		coveredTo = c.end
+	}
+	return n.Duration - covered
+}
+
+// FlameGraph merges the span trees of traces into flame graph frames.
+func FlameGraph(traces ...aws.TraceDetails) []FlameNode {
+	roots := make([]FlameNode, 0)
+	for _, td := range traces {
+		for _, n := range SpanTree(td) {
+			roots = mergeFlameNode(roots, n)
+		}
+	}
+	finishFlameNodes(roots)
+	return roots
+}
+
+func mergeFlameNode(frames []FlameNode, n SpanNode) []FlameNode {
+	i := slices.IndexFunc(frames, func(f FlameNode) bool { return f.Name == n.Name })
+	if i < 0 {
+		frames = append(frames, FlameNode{Name: n.Name})
+		i = len(frames) - 1
+	}
+	frames[i].Self += SelfTime(n)
+	for _, c := range n.Children {
+		frames[i].Children = mergeFlameNode(frames[i].Children, c)
+	}
+	return frames
+}
+
+// finishFlameNodes works out the totals, and sorts frames by name as flame
+// graphs usually are.
+func finishFlameNodes(frames []FlameNode) {
+	for i := range frames {
+		finishFlameNodes(frames[i].Children)
+		frames[i].Total = frames[i].Self
+		for _, c := range frames[i].Children {
+			frames[i].Total += c.Total
+		}
+	}
+	sort.Slice(frames, func(i, j int) bool { return frames[i].Name < frames[j].Name })
+}
+
+// FoldedStacks writes frames in Brendan Gregg's folded stack format, one
+// line per stack with its self time in microseconds, for flamegraph.pl and
+// similar tools.
+func FoldedStacks(frames []FlameNode) string {
+	var b strings.Builder
+	writeFoldedStacks(&b, frames, "")
+	return b.String()
+}
+
+func writeFoldedStacks(b *strings.Builder, frames []FlameNode, prefix string) {
+	for _, f := range frames {
+		// Semicolons separate frames, so can't appear in names
+		stack := prefix + strings.ReplaceAll(f.Name, ";", ":")
+		if micros := f.Self.Microseconds(); micros > 0 {
+			fmt.Fprintf(b, "%s %d\n", stack, micros)
+		}
+		writeFoldedStacks(b, f.Children, stack+";")
+	}
+}
@@ -0,0 +1,58 @@
+package analysis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zopu/tracey/internal/analysis"
+)
+
+func TestSelfTime(t *testing.T) {
+	ms := time.Millisecond
+	span := analysis.SpanNode{
+		Offset:   0,
+		Duration: 100 * ms,
+		Children: []analysis.SpanNode{
+			// Two calls in parallel, then one that runs past the end
+			{Offset: 10 * ms, Duration: 30 * ms},
+			{Offset: 20 * ms, Duration: 30 * ms},
+			{Offset: 80 * ms, Duration: 40 * ms},
+		},
+	}
+	if got := analysis.SelfTime(span); got != 40*ms {
+		t.Errorf("expected 40ms of self time, got %s", got)
+	}
+}
+
+func TestFoldedStacks(t *testing.T) {
+	td := parseSegments(t, apiSegment, usersSegment)
+	got := analysis.FoldedStacks(analysis.FlameGraph(td))
+	want := "api 200000\n" +
+		"api;DynamoDB 300000\n" +
+		"api;users 100000\n" +
+		"api;users;users 300000\n" +
+		"api;users;users;db 100000\n"
+	if got != want {
+		t.Errorf("expected folded stacks\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestFlameGraphMergesTraces(t *testing.T) {
+	roots := analysis.FlameGraph(
+		parseSegments(t, apiSegment, usersSegment),
+		parseSegments(t, slowAPISegment, slowUsersSegment),
+	)
+	if len(roots) != 1 || roots[0].Name != "api" {
+		t.Fatalf("expected a single api root, got %+v", roots)
+	}
+	if got := roots[0].Total.Round(time.Millisecond); got != 2500*time.Millisecond {
+		t.Errorf("expected 2.5s in total, got %s", got)
+	}
+	names := []string{}
+	for _, c := range roots[0].Children {
+		names = append(names, c.Name)
+	}
+	if len(names) != 3 || names[0] != "DynamoDB" || names[1] != "audit" || names[2] != "users" {
+		t.Errorf("expected DynamoDB, audit and users under api, got %v", names)
+	}
+}
@@ -0,0 +1,197 @@
+package analysis
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+// LatencyStats summarises the response times and outcomes of some traces.
+type LatencyStats struct {
+	Count  int
+	Errors int
+	Faults int
+	Mean   time.Duration
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+func (s LatencyStats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors+s.Faults) / float64(s.Count)
+}
+
+func Summarize(traces []aws.TraceSummary) LatencyStats {
+	stats := LatencyStats{Count: len(traces)}
+	if len(traces) == 0 {
+		return stats
+	}
+	durations := make([]time.Duration, len(traces))
+	var total time.Duration
+	for i, t := range traces {
+		durations[i] = t.ResponseTime()
+		total += durations[i]
+		switch {
+		case t.HasFault():
+			stats.Faults++
+		case t.HasError():
+			stats.Errors++
+		}
+	}
+	slices.Sort(durations)
+	stats.Mean = total / time.Duration(len(durations))
+	stats.P50 = Percentile(durations, 50)
+	stats.P90 = Percentile(durations, 90)
+	stats.P99 = Percentile(durations, 99)
+	stats.Max = durations[len(durations)-1]
+	return stats
+}
+
+// Percentile returns the nearest-rank percentile (0-100) of sorted durations.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank-1, 0), len(sorted)-1)]
+}
+
+// HistogramBucket counts the traces with Low <= response time < High. The
+// last bucket has no upper bound, shown by a zero High.
+type HistogramBucket struct {
+	Low   time.Duration
+	High  time.Duration
+	Count int
+}
+
+func (b HistogramBucket) Contains(d time.Duration) bool {
+	return d >= b.Low && (b.High == 0 || d < b.High)
+}
+
+// Bucket boundaries follow a 1-2-5 progression so they stay readable.
+var histogramBounds = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// LatencyHistogram buckets traces by response time. Empty buckets at either
+// end are dropped.
+func LatencyHistogram(traces []aws.TraceSummary) []HistogramBucket {
+	buckets := make([]HistogramBucket, 0, len(histogramBounds)+1)
+	low := time.Duration(0)
+	for _, high := range histogramBounds {
+		buckets = append(buckets, HistogramBucket{Low: low, High: high})
+		low = high
+	}
+	buckets = append(buckets, HistogramBucket{Low: low})
+
+	for _, t := range traces {
+		for i := range buckets {
+			if buckets[i].Contains(t.ResponseTime()) {
+				buckets[i].Count++
+				break
+			}
+		}
+	}
+
+	first := slices.IndexFunc(buckets, func(b HistogramBucket) bool { return b.Count > 0 })
+	if first < 0 {
+		return []HistogramBucket{}
+	}
+	last := len(buckets) - 1
+	for buckets[last].Count == 0 {
+		last--
+	}
+	return buckets[first : last+1]
+}
+
+// A GroupKey picks the value traces are grouped by.
+type GroupKey struct {
+	Name string
+	Key  func(aws.TraceSummary) string
+}
+
+var GroupKeys = []GroupKey{
+	{Name: "path", Key: func(t aws.TraceSummary) string { return NormalizePath(t.Operation()) }},
+	{Name: "method", Key: aws.TraceSummary.Method},
+	{Name: "status", Key: aws.TraceSummary.StatusText},
+}
+
+type Group struct {
+	Key    string
+	Stats  LatencyStats
+	Traces []aws.TraceSummary
+}
+
+// GroupTraces groups traces by key, busiest groups first.
+func GroupTraces(traces []aws.TraceSummary, key GroupKey) []Group {
+	byKey := map[string][]aws.TraceSummary{}
+	for _, t := range traces {
+		k := key.Key(t)
+		byKey[k] = append(byKey[k], t)
+	}
+	groups := make([]Group, 0, len(byKey))
+	for k, ts := range byKey {
+		groups = append(groups, Group{Key: k, Stats: Summarize(ts), Traces: ts})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Stats.Count != groups[j].Stats.Count {
+			return groups[i].Stats.Count > groups[j].Stats.Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexPattern  = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	// Long tokens mixing letters and digits, like ULIDs or base64 IDs
+	tokenPattern = regexp.MustCompile(`^[0-9A-Za-z_-]{20,}$`)
+)
+
+// NormalizePath replaces the parameter-like segments of a path with ":id",
+// so /users/123 and /users/456 are grouped together as /users/:id.
+func NormalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if isPathParameter(s) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func isPathParameter(s string) bool {
+	if s == "" {
+		return false
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return true
+	}
+	if uuidPattern.MatchString(s) || hexPattern.MatchString(s) {
+		return true
+	}
+	return tokenPattern.MatchString(s) && strings.ContainsAny(s, "0123456789")
+}
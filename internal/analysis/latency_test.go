@@ -0,0 +1,70 @@
+package analysis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/xray/types"
+	"github.com/samber/lo"
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/aws"
+)
+
+func traceWithResponseTime(seconds float64) aws.TraceSummary {
+	return aws.TraceSummary{
+		Data: types.TraceSummary{
+			Id:           lo.ToPtr("1-5f84c7a1-e5b6a6b7c8d9e0f1a2b3c4d5"),
+			ResponseTime: lo.ToPtr(seconds),
+		},
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	cases := map[string]string{
+		"/users/123/orders": "/users/:id/orders",
+		"/users/me":         "/users/me",
+		"/items/3f2b8c1e-9d4a-4b6e-8f1a-2c3d4e5f6a7b": "/items/:id",
+		"/files/01HF7Z3K9QW8X2V5T6Y4R1N0MB":           "/files/:id",
+		"/health":                                     "/health",
+	}
+	for path, expected := range cases {
+		if got := analysis.NormalizePath(path); got != expected {
+			t.Errorf("NormalizePath(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+	if p := analysis.Percentile(sorted, 50); p != 50*time.Millisecond {
+		t.Errorf("Expected p50 of 50ms, got %s", p)
+	}
+	if p := analysis.Percentile(sorted, 99); p != 99*time.Millisecond {
+		t.Errorf("Expected p99 of 99ms, got %s", p)
+	}
+	if p := analysis.Percentile(nil, 50); p != 0 {
+		t.Errorf("Expected 0 for no durations, got %s", p)
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	traces := []aws.TraceSummary{
+		traceWithResponseTime(0.003),
+		traceWithResponseTime(0.004),
+		traceWithResponseTime(0.150),
+	}
+	buckets := analysis.LatencyHistogram(traces)
+	// 2-5ms through 100-200ms, with the empty ends trimmed
+	if len(buckets) != 6 {
+		t.Fatalf("Expected 6 buckets, got %+v", buckets)
+	}
+	if buckets[0].Low != 2*time.Millisecond || buckets[0].Count != 2 {
+		t.Errorf("Expected two traces in the 2-5ms bucket, got %+v", buckets[0])
+	}
+	if last := buckets[len(buckets)-1]; last.High != 200*time.Millisecond || last.Count != 1 {
+		t.Errorf("Expected one trace in the 100-200ms bucket, got %+v", last)
+	}
+}
@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/config"
+)
+
+// TraceIDsInLogs finds the trace IDs in log messages, in the order they're
+// first seen, with the logs config's trace ID query or pattern. By default
+// it finds anything that looks like an X-Ray trace ID.
+func TraceIDsInLogs(messages []string, logs config.Logs) []aws.TraceID {
+	seen := map[aws.TraceID]bool{}
+	ids := make([]aws.TraceID, 0)
+	add := func(id string) {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[aws.TraceID(id)] {
+			seen[aws.TraceID(id)] = true
+			ids = append(ids, aws.TraceID(id))
+		}
+	}
+
+	for _, message := range messages {
+		if logs.ParsedTraceIDQuery != nil {
+			var event any
+			if err := json.Unmarshal([]byte(message), &event); err != nil {
+				continue
+			}
+			it := logs.ParsedTraceIDQuery.Run(event)
+			for {
+				v, ok := it.Next()
+				if !ok {
+					break
+				}
+				if id, isString := v.(string); isString {
+					add(id)
+				}
+			}
+			continue
+		}
+
+		pattern := aws.TraceIDPattern
+		if logs.ParsedTraceIDPattern != nil {
+			pattern = logs.ParsedTraceIDPattern
+		}
+		for _, match := range pattern.FindAllStringSubmatch(message, -1) {
+			add(match[min(len(match)-1, 1)])
+		}
+	}
+	return ids
+}
@@ -0,0 +1,40 @@
+package analysis_test
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/itchyny/gojq"
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/config"
+)
+
+func TestTraceIDsInLogs(t *testing.T) {
+	messages := []string{
+		`{"level": "error", "msg": "payment failed", "traceId": "1-5759e988-bd862e3fe1be46a994272793"}`,
+		`ERROR payment failed Root=1-6759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8 req=abc`,
+		`retrying 1-5759e988-bd862e3fe1be46a994272793`,
+	}
+
+	ids := analysis.TraceIDsInLogs(messages, config.Logs{})
+	expected := []aws.TraceID{"1-5759e988-bd862e3fe1be46a994272793", "1-6759e988-bd862e3fe1be46a994272793"}
+	if !slices.Equal(ids, expected) {
+		t.Errorf("Expected %v by default, got %v", expected, ids)
+	}
+
+	query, err := gojq.Parse(".traceId")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids = analysis.TraceIDsInLogs(messages, config.Logs{ParsedTraceIDQuery: query})
+	if !slices.Equal(ids, expected[:1]) {
+		t.Errorf("Expected only the JSON event's ID with a query, got %v", ids)
+	}
+
+	ids = analysis.TraceIDsInLogs(messages, config.Logs{ParsedTraceIDPattern: regexp.MustCompile(`req=(\w+)`)})
+	if !slices.Equal(ids, []aws.TraceID{"abc"}) {
+		t.Errorf("Expected the pattern's group, got %v", ids)
+	}
+}
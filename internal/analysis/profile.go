@@ -0,0 +1,89 @@
+package analysis
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+// SpanProfile summarises the spans with one name across many traces.
+type SpanProfile struct {
+	Name string
+	// Number of spans, which can be more than one per trace
+	Count int
+	// Number of traces the span appeared in
+	Traces int
+	Mean   time.Duration
+	P95    time.Duration
+	Total  time.Duration
+	// Share of the traces' total duration spent in these spans. Nested
+	// spans are counted in their parents' time too.
+	Share float64
+}
+
+// ProfileSpans aggregates the subsegments of many traces by name, busiest
+// first, to show where time goes across requests.
+func ProfileSpans(traces []aws.TraceDetails) []SpanProfile {
+	durations := map[string][]time.Duration{}
+	traceCounts := map[string]int{}
+	var traceTotal time.Duration
+	for _, td := range traces {
+		roots := SpanTree(td)
+		traceTotal += TraceDuration(roots)
+		seen := map[string]bool{}
+		for _, root := range roots {
+			WalkSpans(root.Children, func(n SpanNode, _ int) bool {
+				if n.Segment {
+					// Downstream segments repeat the subsegment that called them
+					return true
+				}
+				durations[n.Name] = append(durations[n.Name], n.Duration)
+				if !seen[n.Name] {
+					seen[n.Name] = true
+					traceCounts[n.Name]++
+				}
+				return true
+			})
+		}
+	}
+
+	profiles := make([]SpanProfile, 0, len(durations))
+	for name, ds := range durations {
+		slices.Sort(ds)
+		var total time.Duration
+		for _, d := range ds {
+			total += d
+		}
+		p := SpanProfile{
+			Name:   name,
+			Count:  len(ds),
+			Traces: traceCounts[name],
+			Mean:   total / time.Duration(len(ds)),
+			P95:    Percentile(ds, 95),
+			Total:  total,
+		}
+		if traceTotal > 0 {
+			p.Share = float64(total) / float64(traceTotal)
+		}
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Total != profiles[j].Total {
+			return profiles[i].Total > profiles[j].Total
+		}
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
+
+// TraceDuration is the time from the start of the first span to the end of
+// the last.
+func TraceDuration(roots []SpanNode) time.Duration {
+	var end time.Duration
+	for _, r := range roots {
+		end = max(end, r.Offset+r.Duration)
+	}
+	return end
+}
@@ -0,0 +1,50 @@
+package analysis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/aws"
+)
+
+func TestProfileSpans(t *testing.T) {
+	traces := []aws.TraceDetails{
+		parseSegments(t, apiSegment, usersSegment),
+		parseSegments(t, slowAPISegment, slowUsersSegment),
+	}
+	profiles := analysis.ProfileSpans(traces)
+
+	want := []struct {
+		name   string
+		count  int
+		traces int
+		mean   time.Duration
+		p95    time.Duration
+		total  time.Duration
+		share  float64
+	}{
+		{"users", 2, 2, 750 * time.Millisecond, time.Second, 1500 * time.Millisecond, 0.6},
+		{"db", 2, 2, 450 * time.Millisecond, 800 * time.Millisecond, 900 * time.Millisecond, 0.36},
+		{"DynamoDB", 3, 2, 133 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 0.16},
+		{"audit", 1, 1, 200 * time.Millisecond, 200 * time.Millisecond, 200 * time.Millisecond, 0.08},
+	}
+	if len(profiles) != len(want) {
+		t.Fatalf("expected %d profiles, got %+v", len(want), profiles)
+	}
+	for i, w := range want {
+		p := profiles[i]
+		if p.Name != w.name || p.Count != w.count || p.Traces != w.traces {
+			t.Errorf("profile %d: expected %s x%d in %d traces, got %s x%d in %d traces",
+				i, w.name, w.count, w.traces, p.Name, p.Count, p.Traces)
+		}
+		if p.Mean.Round(time.Millisecond) != w.mean || p.P95.Round(time.Millisecond) != w.p95 ||
+			p.Total.Round(time.Millisecond) != w.total {
+			t.Errorf("%s: expected mean %s p95 %s total %s, got %s %s %s",
+				w.name, w.mean, w.p95, w.total, p.Mean, p.P95, p.Total)
+		}
+		if p.Share < w.share-0.001 || p.Share > w.share+0.001 {
+			t.Errorf("%s: expected share %.2f, got %.4f", w.name, w.share, p.Share)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package analysis
+
+import (
+	"slices"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/config"
+)
+
+// RuleMatch is a configured rule that flagged a trace.
+type RuleMatch struct {
+	Rule     string
+	Severity string
+	Message  string
+	// The spans the rule matched
+	SpanIDs []string
+}
+
+// CheckRules runs rules over every segment and subsegment of a trace,
+// returning the rules that matched at least their minimum number of spans.
+func CheckRules(td aws.TraceDetails, rules []config.Rule) []RuleMatch {
+	matches := make([]RuleMatch, 0)
+	for _, rule := range rules {
+		ids := make([]string, 0)
+		for _, s := range td.Segments {
+			walkDocuments(s.Document, func(doc map[string]any) {
+				if ruleMatchesSpan(rule, doc) {
+					id, _ := doc["id"].(string)
+					ids = append(ids, id)
+				}
+			})
+		}
+		if len(ids) > 0 && len(ids) >= rule.MinCount {
+			matches = append(matches, RuleMatch{
+				Rule:     rule.Name,
+				Severity: rule.Severity,
+				Message:  rule.Message,
+				SpanIDs:  ids,
+			})
+		}
+	}
+	return matches
+}
+
+// MostSevere is the highest severity among matches, or "" if there are none.
+func MostSevere(matches []RuleMatch) string {
+	order := []string{config.SeverityInfo, config.SeverityWarning, config.SeverityError}
+	most := -1
+	for _, m := range matches {
+		most = max(most, slices.Index(order, m.Severity))
+	}
+	if most < 0 {
+		return ""
+	}
+	return order[most]
+}
+
+// walkDocuments visits a segment document and all the subsegments in it.
+func walkDocuments(doc map[string]any, visit func(map[string]any)) {
+	if doc == nil {
+		return
+	}
+	visit(doc)
+	subsegments, _ := doc["subsegments"].([]any)
+	for _, sub := range subsegments {
+		if m, ok := sub.(map[string]any); ok {
+			walkDocuments(m, visit)
+		}
+	}
+}
+
+func ruleMatchesSpan(rule config.Rule, doc map[string]any) bool {
+	if rule.ParsedQuery != nil {
+		it := rule.ParsedQuery.Run(doc)
+		for {
+			v, ok := it.Next()
+			if !ok {
+				return false
+			}
+			if v == true {
+				return true
+			}
+		}
+	}
+	if rule.Span != nil {
+		return spanPredicateMatches(*rule.Span, doc)
+	}
+	return false
+}
+
+//nolint:gocognit,gocyclo // A condition for each predicate field
+func spanPredicateMatches(p config.SpanPredicate, doc map[string]any) bool {
+	str := func(v any) string {
+		s, _ := v.(string)
+		return s
+	}
+	num := func(v any) float64 {
+		f, _ := v.(float64)
+		return f
+	}
+	object := func(v any) map[string]any {
+		m, _ := v.(map[string]any)
+		return m
+	}
+
+	if p.ParsedName != nil && !p.ParsedName.MatchString(str(doc["name"])) {
+		return false
+	}
+	if p.ParsedNamespace != nil && !p.ParsedNamespace.MatchString(str(doc["namespace"])) {
+		return false
+	}
+	if p.ParsedMinDuration > 0 {
+		end, ok := doc["end_time"].(float64)
+		if !ok {
+			return false
+		}
+		duration := time.Duration((end - num(doc["start_time"])) * float64(time.Second))
+		if duration < p.ParsedMinDuration {
+			return false
+		}
+	}
+	if (p.Error && doc["error"] != true) || (p.Fault && doc["fault"] != true) || (p.Throttle && doc["throttle"] != true) {
+		return false
+	}
+	if len(p.HTTPStatus) > 0 {
+		status := int(num(object(object(doc["http"])["response"])["status"]))
+		if !slices.Contains(p.HTTPStatus, status) {
+			return false
+		}
+	}
+	if p.MinRetries > 0 && num(object(doc["aws"])["retries"]) < float64(p.MinRetries) {
+		return false
+	}
+	if p.ParsedSQL != nil || p.ParsedNotSQL != nil {
+		query, ok := object(doc["sql"])["sanitized_query"].(string)
+		if !ok {
+			return false
+		}
+		if p.ParsedSQL != nil && !p.ParsedSQL.MatchString(query) {
+			return false
+		}
+		if p.ParsedNotSQL != nil && p.ParsedNotSQL.MatchString(query) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package analysis_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/itchyny/gojq"
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/config"
+)
+
+const retryingSegment = `{
+	"name": "api", "id": "a1", "start_time": 100.0, "end_time": 102.0,
+	"subsegments": [
+		{"name": "DynamoDB", "id": "s1", "namespace": "aws", "start_time": 100.0, "end_time": 100.1,
+		 "aws": {"operation": "GetItem", "retries": 2}},
+		{"name": "DynamoDB", "id": "s2", "namespace": "aws", "start_time": 100.1, "end_time": 100.2},
+		{"name": "payments", "id": "s3", "namespace": "remote", "start_time": 100.2, "end_time": 101.5,
+		 "http": {"response": {"status": 429}}, "throttle": true},
+		{"name": "db", "id": "s4", "start_time": 101.5, "end_time": 101.6,
+		 "sql": {"sanitized_query": "DELETE FROM sessions"}}
+	]
+}`
+
+func TestCheckRules(t *testing.T) {
+	retries, err := gojq.Parse(".aws.retries > 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := []config.Rule{
+		{Name: "retries", Severity: config.SeverityInfo, Message: "AWS call retried", ParsedQuery: retries},
+		{Name: "slow", Severity: config.SeverityWarning, Message: "Slow span", Span: &config.SpanPredicate{
+			ParsedNamespace: regexp.MustCompile("^remote$"), ParsedMinDuration: time.Second,
+		}},
+		{Name: "rate-limited", Severity: config.SeverityError, Message: "Rate limited downstream",
+			Span: &config.SpanPredicate{HTTPStatus: []int{429}, Throttle: true}},
+		{Name: "no-where", Severity: config.SeverityError, Message: "SQL without WHERE", Span: &config.SpanPredicate{
+			ParsedSQL: regexp.MustCompile(`(?i)^\s*(update|delete)\b`), ParsedNotSQL: regexp.MustCompile(`(?i)\bwhere\b`),
+		}},
+		{Name: "chatty", Severity: config.SeverityWarning, Message: "Many DynamoDB calls", MinCount: 3,
+			Span: &config.SpanPredicate{ParsedName: regexp.MustCompile("^DynamoDB$")}},
+	}
+
+	matches := analysis.CheckRules(parseSegments(t, retryingSegment), rules)
+	want := map[string][]string{
+		"retries":      {"s1"},
+		"slow":         {"s3"},
+		"rate-limited": {"s3"},
+		"no-where":     {"s4"},
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("expected %d matching rules, got %+v", len(want), matches)
+	}
+	for _, m := range matches {
+		ids, ok := want[m.Rule]
+		if !ok || len(m.SpanIDs) != len(ids) || m.SpanIDs[0] != ids[0] {
+			t.Errorf("rule %s: expected spans %v, got %v", m.Rule, ids, m.SpanIDs)
+		}
+	}
+	if got := analysis.MostSevere(matches); got != config.SeverityError {
+		t.Errorf("expected the most severe match to be an error, got %q", got)
+	}
+}
@@ -0,0 +1,253 @@
+// Package analysis derives higher level views from trace data: how services
+// call each other, where time is spent and what looks wrong.
+package analysis
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+// ServiceGraph is the graph of services that called each other in a trace.
+type ServiceGraph struct {
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+type GraphNode struct {
+	ID   string
+	Name string
+	Type string
+	// Inferred nodes are downstream services that didn't send their own
+	// segments, like DynamoDB or a SQL database.
+	Inferred bool
+}
+
+type GraphEdge struct {
+	From   string
+	To     string
+	Calls  int
+	Total  time.Duration
+	Errors int
+	Faults int
+}
+
+func (g ServiceGraph) Node(id string) (GraphNode, bool) {
+	for _, n := range g.Nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+	return GraphNode{}, false
+}
+
+// Roots are the nodes nothing calls, usually the service the request entered.
+func (g ServiceGraph) Roots() []GraphNode {
+	called := make(map[string]bool, len(g.Edges))
+	for _, e := range g.Edges {
+		called[e.To] = true
+	}
+	roots := make([]GraphNode, 0)
+	for _, n := range g.Nodes {
+		if !called[n.ID] {
+			roots = append(roots, n)
+		}
+	}
+	return roots
+}
+
+// EdgesFrom returns the calls a node made, in the order they were first seen.
+func (g ServiceGraph) EdgesFrom(id string) []GraphEdge {
+	edges := make([]GraphEdge, 0)
+	for _, e := range g.Edges {
+		if e.From == id {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+type graphBuilder struct {
+	graph     ServiceGraph
+	nodeIndex map[string]int
+	edgeIndex map[[2]string]int
+}
+
+func (b *graphBuilder) addNode(n GraphNode) {
+	if _, ok := b.nodeIndex[n.ID]; ok {
+		return
+	}
+	b.nodeIndex[n.ID] = len(b.graph.Nodes)
+	b.graph.Nodes = append(b.graph.Nodes, n)
+}
+
+func (b *graphBuilder) addCall(from, to string, d time.Duration, isError, isFault bool) {
+	key := [2]string{from, to}
+	i, ok := b.edgeIndex[key]
+	if !ok {
+		i = len(b.graph.Edges)
+		b.edgeIndex[key] = i
+		b.graph.Edges = append(b.graph.Edges, GraphEdge{From: from, To: to})
+	}
+	e := &b.graph.Edges[i]
+	e.Calls++
+	e.Total += d
+	if isError {
+		e.Errors++
+	}
+	if isFault {
+		e.Faults++
+	}
+}
+
+func segmentNodeID(s aws.Segment) string {
+	return "segment:" + s.Name
+}
+
+// BuildServiceGraph works out which services called which from the segments
+// of a trace. Calls to traced services become edges between their segments;
+// calls to AWS services, SQL databases and untraced remote services become
+// edges to inferred nodes.
+func BuildServiceGraph(td aws.TraceDetails) ServiceGraph {
+	b := graphBuilder{
+		nodeIndex: map[string]int{},
+		edgeIndex: map[[2]string]int{},
+	}
+
+	// Downstream segments record the subsegment that called them as their
+	// parent, which is how calls between traced services are linked up.
+	downstream := map[string][]aws.Segment{}
+	for _, segment := range td.Segments {
+		b.addNode(GraphNode{ID: segmentNodeID(segment), Name: segment.Name, Type: segment.Origin})
+		if segment.ParentID != "" {
+			downstream[segment.ParentID] = append(downstream[segment.ParentID], segment)
+		}
+	}
+
+	for _, segment := range td.Segments {
+		from := segmentNodeID(segment)
+		WalkSubSegments(segment.SubSegments, func(sub aws.SubSegment, _ int) bool {
+			if called, ok := downstream[sub.ID]; ok {
+				for _, c := range called {
+					b.addCall(from, segmentNodeID(c), sub.Duration(), sub.Error, sub.Fault)
+				}
+				return false
+			}
+			node, ok := inferredNode(sub)
+			if !ok {
+				return true
+			}
+			b.addNode(node)
+			b.addCall(from, node.ID, sub.Duration(), sub.Error, sub.Fault)
+			// Anything below a downstream call (e.g. retries) is part of it
+			return false
+		})
+	}
+	return b.graph
+}
+
+// inferredNode identifies the downstream service a subsegment called, if any.
+func inferredNode(sub aws.SubSegment) (GraphNode, bool) {
+	if sql, ok := sub.SQL.Get(); ok {
+		name := sub.Name
+		if u, err := url.Parse(sql.URL); err == nil && u.Host != "" {
+			name = u.Host + u.Path
+		} else if sql.URL != "" {
+			name = sql.URL
+		}
+		dbType := "Database::SQL"
+		if sql.DatabaseType != "" {
+			dbType += "::" + sql.DatabaseType
+		}
+		return GraphNode{ID: "sql:" + name, Name: name, Type: dbType, Inferred: true}, true
+	}
+	switch sub.Namespace {
+	case "aws":
+		return GraphNode{ID: "aws:" + sub.Name, Name: sub.Name, Type: "AWS::" + sub.Name, Inferred: true}, true
+	case "remote":
+		return GraphNode{ID: "remote:" + sub.Name, Name: sub.Name, Type: "remote", Inferred: true}, true
+	}
+	return GraphNode{}, false
+}
+
+// WalkSubSegments visits subsegments depth first, passing each one's depth.
+// Returning false from visit skips that subsegment's children.
+func WalkSubSegments(subsegments []aws.SubSegment, visit func(aws.SubSegment, int) bool) {
+	walkSubSegments(subsegments, 0, visit)
+}
+
+func walkSubSegments(subsegments []aws.SubSegment, depth int, visit func(aws.SubSegment, int) bool) {
+	for _, sub := range subsegments {
+		if visit(sub, depth) {
+			walkSubSegments(sub.SubSegments, depth+1, visit)
+		}
+	}
+}
+
+func (e GraphEdge) Label() string {
+	calls := "call"
+	if e.Calls != 1 {
+		calls = "calls"
+	}
+	return fmt.Sprintf("%d %s, %s", e.Calls, calls, e.Total.Round(time.Millisecond))
+}
+
+// DOT renders the graph in Graphviz format.
+func (g ServiceGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph trace {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		label := n.Name
+		if n.Type != "" {
+			label += "\\n" + n.Type
+		}
+		style := ""
+		if n.Inferred {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s%s];\n", dotQuote(n.ID), dotQuote(label), style)
+	}
+	for _, e := range g.Edges {
+		color := ""
+		if e.Faults > 0 || e.Errors > 0 {
+			color = ", color=red"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Label()), color)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// Mermaid renders the graph as a Mermaid flowchart.
+func (g ServiceGraph) Mermaid() string {
+	ids := make(map[string]string, len(g.Nodes))
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		label := n.Name
+		if n.Type != "" {
+			label += "<br/>" + n.Type
+		}
+		open, closing := "[", "]"
+		if n.Inferred {
+			open, closing = "[(", ")]"
+		}
+		fmt.Fprintf(&b, "  %s%s%s%s\n", ids[n.ID], open, mermaidQuote(label), closing)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", ids[e.From], mermaidQuote(e.Label()), ids[e.To])
+	}
+	return b.String()
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
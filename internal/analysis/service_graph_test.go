@@ -0,0 +1,87 @@
+package analysis_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/aws"
+)
+
+// parseSegments builds trace details from raw segment documents.
+func parseSegments(t *testing.T, docs ...string) aws.TraceDetails {
+	t.Helper()
+	td := aws.TraceDetails{ID: "1-5f84c7a1-e5b6a6b7c8d9e0f1a2b3c4d5"}
+	for _, doc := range docs {
+		var s aws.Segment
+		if err := json.Unmarshal([]byte(doc), &s); err != nil {
+			t.Fatalf("failed to parse segment: %v", err)
+		}
+		td.Segments = append(td.Segments, s)
+	}
+	return td
+}
+
+const (
+	apiSegment = `{
+		"name": "api", "id": "a1", "origin": "AWS::ECS::Container",
+		"start_time": 100.0, "end_time": 101.0,
+		"subsegments": [
+			{"name": "DynamoDB", "id": "s1", "namespace": "aws", "start_time": 100.1, "end_time": 100.2},
+			{"name": "DynamoDB", "id": "s2", "namespace": "aws", "start_time": 100.2, "end_time": 100.4},
+			{"name": "users", "id": "s3", "namespace": "remote", "start_time": 100.4, "end_time": 100.9}
+		]
+	}`
+	usersSegment = `{
+		"name": "users", "id": "u1", "parent_id": "s3",
+		"start_time": 100.45, "end_time": 100.85,
+		"subsegments": [
+			{"name": "db", "id": "s4", "start_time": 100.5, "end_time": 100.6,
+			 "sql": {"url": "postgres://db.internal:5432/users", "database_type": "PostgreSQL"}}
+		]
+	}`
+)
+
+func TestBuildServiceGraph(t *testing.T) {
+	g := analysis.BuildServiceGraph(parseSegments(t, apiSegment, usersSegment))
+
+	if len(g.Nodes) != 4 {
+		t.Fatalf("Expected 4 nodes, got %d: %+v", len(g.Nodes), g.Nodes)
+	}
+	roots := g.Roots()
+	if len(roots) != 1 || roots[0].Name != "api" {
+		t.Errorf("Expected api to be the only root, got %+v", roots)
+	}
+
+	edges := g.EdgesFrom("segment:api")
+	if len(edges) != 2 {
+		t.Fatalf("Expected 2 edges from api, got %+v", edges)
+	}
+	if edges[0].To != "aws:DynamoDB" || edges[0].Calls != 2 {
+		t.Errorf("Expected 2 calls to DynamoDB, got %+v", edges[0])
+	}
+	// The call to users was traced, so it links to the users segment rather
+	// than an inferred remote node
+	if edges[1].To != "segment:users" {
+		t.Errorf("Expected a call to the users segment, got %+v", edges[1])
+	}
+
+	sqlEdges := g.EdgesFrom("segment:users")
+	if len(sqlEdges) != 1 || sqlEdges[0].To != "sql:db.internal:5432/users" {
+		t.Errorf("Expected a call to the users database, got %+v", sqlEdges)
+	}
+}
+
+func TestServiceGraphExports(t *testing.T) {
+	g := analysis.BuildServiceGraph(parseSegments(t, apiSegment, usersSegment))
+
+	dot := g.DOT()
+	if !strings.Contains(dot, `"segment:api" -> "aws:DynamoDB" [label="2 calls, 300ms"]`) {
+		t.Errorf("Unexpected DOT output:\n%s", dot)
+	}
+	mermaid := g.Mermaid()
+	if !strings.HasPrefix(mermaid, "graph LR\n") || !strings.Contains(mermaid, `-->|"2 calls, 300ms"|`) {
+		t.Errorf("Unexpected Mermaid output:\n%s", mermaid)
+	}
+}
@@ -0,0 +1,154 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+
+	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/aws"
+)
+
+// SpanNode is a segment or subsegment of a trace, with the spans it contains.
+// Segments sent by downstream services are nested under the subsegment that
+// called them, so the tree follows the request from start to finish.
+type SpanNode struct {
+	ID   string
+	Name string
+	// The segment's origin, or the subsegment's namespace
+	Type string
+	// Segment is false for subsegments
+	Segment bool
+	// Time from the start of the trace
+	Offset      time.Duration
+	Duration    time.Duration
+	SQL         mo.Option[aws.SQL]
+	Annotations map[string]any
+	Metadata    map[string]any
+	Error       bool
+	Fault       bool
+	Throttle    bool
+	Children    []SpanNode
+}
+
+// SpanTree arranges the segments of a trace into trees, one for each segment
+// without a known parent.
+func SpanTree(td aws.TraceDetails) []SpanNode {
+	if len(td.Segments) == 0 {
+		return []SpanNode{}
+	}
+	start := td.Segments[0].StartTime.Time()
+	for _, s := range td.Segments {
+		if s.StartTime.Time().Before(start) {
+			start = s.StartTime.Time()
+		}
+	}
+
+	// Work out which segments have a parent subsegment in this trace
+	subsegmentIDs := map[string]bool{}
+	for _, s := range td.Segments {
+		WalkSubSegments(s.SubSegments, func(sub aws.SubSegment, _ int) bool {
+			subsegmentIDs[sub.ID] = true
+			return true
+		})
+	}
+	downstream := map[string][]aws.Segment{}
+	roots := make([]aws.Segment, 0)
+	for _, s := range td.Segments {
+		if s.ParentID != "" && subsegmentIDs[s.ParentID] {
+			downstream[s.ParentID] = append(downstream[s.ParentID], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+
+	b := spanTreeBuilder{start: start, downstream: downstream}
+	nodes := make([]SpanNode, 0, len(roots))
+	for _, s := range roots {
+		nodes = append(nodes, b.segment(s))
+	}
+	sortSpans(nodes)
+	return nodes
+}
+
+type spanTreeBuilder struct {
+	start      time.Time
+	downstream map[string][]aws.Segment
+}
+
+func (b spanTreeBuilder) segment(s aws.Segment) SpanNode {
+	node := SpanNode{
+		ID:          s.ID,
+		Name:        s.Name,
+		Type:        s.Origin,
+		Segment:     true,
+		Offset:      s.StartTime.Time().Sub(b.start),
+		Duration:    s.Duration(),
+		SQL:         s.SQL,
+		Annotations: s.Annotations,
+		Metadata:    s.Metadata,
+		Error:       s.Error,
+		Fault:       s.Fault,
+		Throttle:    s.Throttle,
+	}
+	for _, sub := range s.SubSegments {
+		node.Children = append(node.Children, b.subsegment(sub))
+	}
+	sortSpans(node.Children)
+	return node
+}
+
+func (b spanTreeBuilder) subsegment(s aws.SubSegment) SpanNode {
+	node := SpanNode{
+		ID:          s.ID,
+		Name:        s.Name,
+		Type:        s.Namespace,
+		Offset:      s.StartTime.Time().Sub(b.start),
+		Duration:    s.Duration(),
+		SQL:         s.SQL,
+		Annotations: s.Annotations,
+		Metadata:    s.Metadata,
+		Error:       s.Error,
+		Fault:       s.Fault,
+		Throttle:    s.Throttle,
+	}
+	for _, sub := range s.SubSegments {
+		node.Children = append(node.Children, b.subsegment(sub))
+	}
+	for _, called := range b.downstream[s.ID] {
+		node.Children = append(node.Children, b.segment(called))
+	}
+	sortSpans(node.Children)
+	return node
+}
+
+func sortSpans(nodes []SpanNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Offset < nodes[j].Offset
+	})
+}
+
+// FindSpan looks up a segment or subsegment by ID.
+func FindSpan(td aws.TraceDetails, id string) mo.Option[SpanNode] {
+	found := mo.None[SpanNode]()
+	WalkSpans(SpanTree(td), func(n SpanNode, _ int) bool {
+		if n.ID == id {
+			found = mo.Some(n)
+		}
+		return found.IsAbsent()
+	})
+	return found
+}
+
+// WalkSpans visits spans depth first, passing each one's depth. Returning
+// false from visit skips that span's children.
+func WalkSpans(nodes []SpanNode, visit func(SpanNode, int) bool) {
+	walkSpans(nodes, 0, visit)
+}
+
+func walkSpans(nodes []SpanNode, depth int, visit func(SpanNode, int) bool) {
+	for _, n := range nodes {
+		if visit(n, depth) {
+			walkSpans(n.Children, depth+1, visit)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package analysis
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+// NPlusOneRepeats is how many times in a row a statement has to run under
+// one span to be flagged as a likely N+1 query.
+const NPlusOneRepeats = 5
+
+var (
+	sqlStringPattern      = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumberPattern      = regexp.MustCompile(`\b(?:0x[0-9a-fA-F]+|\d+(?:\.\d+)?)\b`)
+	sqlPlaceholderPattern = regexp.MustCompile(`\$\d+`)
+	sqlInListPattern      = regexp.MustCompile(`(?i)\bIN\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	sqlValuesPattern      = regexp.MustCompile(`(?i)\bVALUES\s*\([^()]*\)(?:\s*,\s*\([^()]*\))+`)
+)
+
+// NormalizeSQL replaces the values in a query with placeholders, so that
+// runs of a statement with different values look the same. IN lists become
+// a single placeholder, and multi-row VALUES lists a single row.
+func NormalizeSQL(query string) string {
+	q := NormalizeWhitespace(query)
+	q = sqlStringPattern.ReplaceAllString(q, "?")
+	q = sqlPlaceholderPattern.ReplaceAllString(q, "?")
+	q = sqlNumberPattern.ReplaceAllString(q, "?")
+	q = sqlInListPattern.ReplaceAllString(q, "IN (?)")
+	return sqlValuesPattern.ReplaceAllStringFunc(q, func(values string) string {
+		return values[:strings.Index(values, ")")+1]
+	})
+}
+
+// SQLStatement sums up the runs of one normalized statement in a trace.
+type SQLStatement struct {
+	Query string
+	Count int
+	Total time.Duration
+	// The most times the statement ran one after another under one span,
+	// and that span's name
+	Repeats    int
+	RepeatedIn string
+}
+
+func (s SQLStatement) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// IsNPlusOne is true for statements that look like they're run once for
+// each row of an earlier query, rather than once for all of them.
+func (s SQLStatement) IsNPlusOne() bool {
+	return s.Repeats >= NPlusOneRepeats
+}
+
+// SQLConnection is a database that queries were sent to.
+type SQLConnection struct {
+	DatabaseType string
+	User         string
+	// The URL, or the connection string if there's no URL
+	URL   string
+	Count int
+	Total time.Duration
+}
+
+type SQLSummary struct {
+	Statements  []SQLStatement
+	Connections []SQLConnection
+}
+
+func (s SQLSummary) Count() int {
+	n := 0
+	for _, st := range s.Statements {
+		n += st.Count
+	}
+	return n
+}
+
+func (s SQLSummary) Total() time.Duration {
+	var total time.Duration
+	for _, st := range s.Statements {
+		total += st.Total
+	}
+	return total
+}
+
+// SummarizeSQL groups the SQL queries in a trace by statement and by
+// connection, slowest first.
+func SummarizeSQL(td aws.TraceDetails) SQLSummary {
+	statements := map[string]*SQLStatement{}
+	connections := map[SQLConnection]*SQLConnection{}
+
+	var visit func(parent SpanNode)
+	visit = func(parent SpanNode) {
+		// The last statement run under the parent, to spot repeats
+		var previousQuery string
+		var previousEnd time.Duration
+		repeats := 0
+		for _, n := range parent.Children {
+			sql, ok := n.SQL.Get()
+			if ok {
+				query := NormalizeSQL(sql.SanitizedQuery)
+				st, seen := statements[query]
+				if !seen {
+					st = &SQLStatement{Query: query}
+					statements[query] = st
+				}
+				st.Count++
+				st.Total += n.Duration
+
+				// Runs that overlap the one before are in parallel, so don't count
+				if query == previousQuery && n.Offset >= previousEnd {
+					repeats++
+				} else {
+					repeats = 1
+				}
+				if repeats > st.Repeats {
+					st.Repeats = repeats
+					st.RepeatedIn = parent.Name
+				}
+				previousQuery, previousEnd = query, n.Offset+n.Duration
+
+				key := connectionFor(sql)
+				c, seen := connections[key]
+				if !seen {
+					c = &key
+					connections[key] = c
+				}
+				c.Count++
+				c.Total += n.Duration
+			}
+			visit(n)
+		}
+	}
+	for _, root := range SpanTree(td) {
+		visit(SpanNode{Children: []SpanNode{root}})
+	}
+
+	summary := SQLSummary{
+		Statements:  make([]SQLStatement, 0, len(statements)),
+		Connections: make([]SQLConnection, 0, len(connections)),
+	}
+	for _, c := range connections {
+		summary.Connections = append(summary.Connections, *c)
+	}
+	sort.Slice(summary.Connections, func(i, j int) bool {
+		a, b := summary.Connections[i], summary.Connections[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.URL < b.URL
+	})
+	for _, st := range statements {
+		summary.Statements = append(summary.Statements, *st)
+	}
+	sort.Slice(summary.Statements, func(i, j int) bool {
+		a, b := summary.Statements[i], summary.Statements[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Query < b.Query
+	})
+	return summary
+}
+
+// connectionFor identifies the connection a query was sent on, without its
+// counts.
+func connectionFor(sql aws.SQL) SQLConnection {
+	url := sql.URL
+	if url == "" {
+		url = sql.ConnectionString
+	}
+	return SQLConnection{DatabaseType: sql.DatabaseType, User: sql.User, URL: url}
+}
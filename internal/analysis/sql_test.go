@@ -0,0 +1,64 @@
+package analysis_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zopu/tracey/internal/analysis"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM users WHERE id = 42", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT *\n  FROM users\n  WHERE name = 'O''Brien' AND score > 1.5", "SELECT * FROM users WHERE name = ? AND score > ?"},
+		{"SELECT * FROM orders WHERE user_id IN (1, 2, 3) AND status IN ('a','b')", "SELECT * FROM orders WHERE user_id IN (?) AND status IN (?)"},
+		{"SELECT * FROM t1 WHERE id = $1", "SELECT * FROM t1 WHERE id = ?"},
+		{"INSERT INTO tags (name, n) VALUES ('a', 1), ('b', 2), ('c', 3)", "INSERT INTO tags (name, n) VALUES (?, ?)"},
+	}
+	for _, tt := range tests {
+		if got := analysis.NormalizeSQL(tt.query); got != tt.want {
+			t.Errorf("NormalizeSQL(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeSQL(t *testing.T) {
+	subsegments := []string{
+		`{"name": "orders", "id": "q0", "start_time": 100.0, "end_time": 100.1,
+		  "sql": {"url": "postgres://db/orders", "user": "app", "database_type": "PostgreSQL", "sanitized_query": "SELECT * FROM orders WHERE status = 'open'"}}`,
+	}
+	for i := 1; i <= 6; i++ {
+		start := 100.1 + float64(i-1)*0.05
+		subsegments = append(subsegments, fmt.Sprintf(
+			`{"name": "users", "id": "q%d", "start_time": %.2f, "end_time": %.2f,
+			  "sql": {"url": "postgres://db/users", "user": "app", "database_type": "PostgreSQL", "sanitized_query": "SELECT * FROM users WHERE id = %d"}}`,
+			i, start, start+0.05, i))
+	}
+	segment := `{"name": "api", "id": "a1", "start_time": 100.0, "end_time": 101.0, "subsegments": [` +
+		strings.Join(subsegments, ",") + `]}`
+
+	summary := analysis.SummarizeSQL(parseSegments(t, segment))
+	if summary.Count() != 7 || len(summary.Statements) != 2 {
+		t.Fatalf("expected 7 queries in 2 statements, got %+v", summary)
+	}
+	users := summary.Statements[0]
+	if users.Query != "SELECT * FROM users WHERE id = ?" || users.Count != 6 ||
+		users.Total.Round(time.Millisecond) != 300*time.Millisecond {
+		t.Errorf("expected 6 user lookups taking 300ms, got %+v", users)
+	}
+	if !users.IsNPlusOne() || users.Repeats != 6 || users.RepeatedIn != "api" {
+		t.Errorf("expected the user lookups to be flagged as N+1 under api, got %+v", users)
+	}
+	if summary.Statements[1].IsNPlusOne() {
+		t.Errorf("didn't expect the orders query to be flagged, got %+v", summary.Statements[1])
+	}
+	if len(summary.Connections) != 2 || summary.Connections[0].URL != "postgres://db/users" ||
+		summary.Connections[0].Count != 6 || summary.Connections[0].User != "app" {
+		t.Errorf("expected the users connection first, got %+v", summary.Connections)
+	}
+}
@@ -0,0 +1,95 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// region is the region switched to, which overrides the default AWS
+// configuration's.
+var region atomic.Value
+
+// SetRegion switches the region AWS is called in.
+func SetRegion(r string) {
+	region.Store(r)
+}
+
+// withRegion loads the configuration in the region switched to, if any.
+func withRegion(o *config.LoadOptions) error {
+	if r, _ := region.Load().(string); r != "" {
+		o.Region = r
+	}
+	return nil
+}
+
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// ParseRegion checks that s looks like a region name, e.g. eu-west-1.
+func ParseRegion(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !regionPattern.MatchString(s) {
+		return "", fmt.Errorf("not a region: %q", s)
+	}
+	return s, nil
+}
+
+// Region is the region of the default AWS configuration, or the one
+// switched to, which may be empty.
+func Region(ctx context.Context) (string, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	return cfg.Region, nil
+}
+
+func consoleURL(region string) string {
+	if region == "" {
+		return "https://console.aws.amazon.com/cloudwatch/home"
+	}
+	return fmt.Sprintf("https://%s.console.aws.amazon.com/cloudwatch/home?region=%s", region, region)
+}
+
+// TraceConsoleURL links to a trace's map and timeline in the CloudWatch
+// console.
+func TraceConsoleURL(region string, id TraceID) string {
+	return consoleURL(region) + "#xray:traces/" + url.PathEscape(string(id))
+}
+
+// LogsInsightsConsoleURL opens a Logs Insights query over log groups and a
+// time range in the CloudWatch console.
+func LogsInsightsConsoleURL(region string, groups []string, query string, start, end time.Time) string {
+	sources := make([]string, len(groups))
+	for i, group := range groups {
+		sources[i] = "~'" + consoleEscape(group)
+	}
+	detail := fmt.Sprintf("~(end~'%s~start~'%s~timeType~'ABSOLUTE~tz~'UTC~editorString~'%s~source~(%s))",
+		consoleEscape(end.UTC().Format(time.RFC3339)),
+		consoleEscape(start.UTC().Format(time.RFC3339)),
+		consoleEscape(query),
+		strings.Join(sources, ""))
+	// The console reads the fragment as a query string, escaped with $
+	// rather than %
+	return consoleURL(region) + "#logsV2:logs-insights$3FqueryDetail$3D" + detail
+}
+
+// consoleEscape escapes values in the console's fragments, which use * in
+// place of % so the escapes survive being escaped again.
+func consoleEscape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "*%02x", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+func TestTraceConsoleURL(t *testing.T) {
+	url := aws.TraceConsoleURL("eu-west-1", "1-5759e988-bd862e3fe1be46a994272793")
+	expected := "https://eu-west-1.console.aws.amazon.com/cloudwatch/home?region=eu-west-1#xray:traces/1-5759e988-bd862e3fe1be46a994272793"
+	if url != expected {
+		t.Errorf("Expected %s, got %s", expected, url)
+	}
+}
+
+func TestLogsInsightsConsoleURL(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	url := aws.LogsInsightsConsoleURL("us-east-1", []string{"/aws/lambda/api"}, "fields @message | limit 5", start, start.Add(time.Hour))
+	expected := "https://us-east-1.console.aws.amazon.com/cloudwatch/home?region=us-east-1#logsV2:logs-insights$3FqueryDetail$3D" +
+		"~(end~'2024-03-01T13*3a00*3a00Z~start~'2024-03-01T12*3a00*3a00Z~timeType~'ABSOLUTE~tz~'UTC" +
+		"~editorString~'fields*20*40message*20*7c*20limit*205~source~(~'*2faws*2flambda*2fapi))"
+	if url != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, url)
+	}
+}
+
+func TestParseRegion(t *testing.T) {
+	for _, s := range []string{"eu-west-1", " US-EAST-2 ", "us-gov-west-1", "ap-southeast-4"} {
+		if _, err := aws.ParseRegion(s); err != nil {
+			t.Errorf("Expected %q to be a region, got %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "europe", "eu-west", "eu west 1"} {
+		if r, err := aws.ParseRegion(s); err == nil {
+			t.Errorf("Expected %q not to be a region, got %s", s, r)
+		}
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/xray"
 	"github.com/aws/aws-sdk-go-v2/service/xray/types"
+	"github.com/samber/lo"
 )
 
 type TraceDetails struct {
@@ -43,7 +45,7 @@ func parseTrace(trace types.Trace) (*TraceDetails, error) {
 }
 
 func FetchTraceDetails(ctx context.Context, id TraceID) (*TraceDetails, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
 	}
@@ -64,3 +66,102 @@ func FetchTraceDetails(ctx context.Context, id TraceID) (*TraceDetails, error) {
 	}
 	return parsed, nil
 }
+
+// BatchGetTraces takes at most this many trace IDs per request.
+const batchGetTracesLimit = 5
+
+// FetchTraceDetailsBatch gets the details of several traces. Traces X-Ray
+// can't find are left out.
+func FetchTraceDetailsBatch(ctx context.Context, ids []TraceID) ([]TraceDetails, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	traces := make([]TraceDetails, 0, len(ids))
+	for _, chunk := range lo.Chunk(ids, batchGetTracesLimit) {
+		input := xray.BatchGetTracesInput{
+			TraceIds: lo.Map(chunk, func(id TraceID, _ int) string { return string(id) }),
+		}
+		paginator := xray.NewBatchGetTracesPaginator(client, &input)
+		for paginator.HasMorePages() {
+			resp, pageErr := paginator.NextPage(ctx)
+			if pageErr != nil {
+				return nil, fmt.Errorf("failed to get trace details, %w", pageErr)
+			}
+			for _, trace := range resp.Traces {
+				parsed, parseErr := parseTrace(trace)
+				if parseErr != nil {
+					return nil, fmt.Errorf("failed to parse trace: %w", parseErr)
+				}
+				traces = append(traces, *parsed)
+			}
+		}
+	}
+	return traces, nil
+}
+
+// Summary approximates the summary X-Ray would list for a trace, from its
+// segments, for traces fetched by ID rather than found by a query.
+func (t TraceDetails) Summary() TraceSummary {
+	id := string(t.ID)
+	data := types.TraceSummary{Id: &id}
+	if len(t.Segments) == 0 {
+		return TraceSummary{Data: data}
+	}
+
+	root := t.Segments[0]
+	if i := slices.IndexFunc(t.Segments, func(s Segment) bool { return s.ParentID == "" }); i >= 0 {
+		root = t.Segments[i]
+	}
+	start, end := t.Segments[0].StartTime.Time(), root.EndTime.Time()
+	var hasError, hasFault, hasThrottle bool
+	annotations := map[string][]types.ValueWithServiceIds{}
+	services := make([]types.ServiceId, 0, len(t.Segments))
+	for _, s := range t.Segments {
+		if s.EndTime.Time().After(end) {
+			end = s.EndTime.Time()
+		}
+		hasError = hasError || s.Error
+		hasFault = hasFault || s.Fault
+		hasThrottle = hasThrottle || s.Throttle
+		services = append(services, types.ServiceId{Name: lo.ToPtr(s.Name)})
+		for key, value := range s.Annotations {
+			if v, ok := annotationValue(value); ok {
+				annotations[key] = append(annotations[key], types.ValueWithServiceIds{AnnotationValue: v})
+			}
+		}
+	}
+
+	data.StartTime = lo.ToPtr(start)
+	data.Duration = lo.ToPtr(end.Sub(start).Seconds())
+	data.ResponseTime = lo.ToPtr(root.Duration().Seconds())
+	data.HasError = &hasError
+	data.HasFault = &hasFault
+	data.HasThrottle = &hasThrottle
+	data.ServiceIds = services
+	data.Annotations = annotations
+	data.EntryPoint = &types.ServiceId{Name: lo.ToPtr(root.Name), Type: lo.EmptyableToPtr(root.Origin)}
+	if req := root.HTTP.Request; req.URL != "" {
+		data.Http = &types.Http{
+			HttpURL:    lo.ToPtr(req.URL),
+			HttpMethod: lo.EmptyableToPtr(req.Method),
+			ClientIp:   lo.EmptyableToPtr(req.ClientIP),
+			HttpStatus: lo.EmptyableToPtr(int32(root.HTTP.Response.Status)),
+		}
+	}
+	return TraceSummary{Data: data}
+}
+
+func annotationValue(v any) (types.AnnotationValue, bool) {
+	switch v := v.(type) {
+	case string:
+		return &types.AnnotationValueMemberStringValue{Value: v}, true
+	case float64:
+		return &types.AnnotationValueMemberNumberValue{Value: v}, true
+	case bool:
+		return &types.AnnotationValueMemberBooleanValue{Value: v}, true
+	}
+	return nil, false
+}
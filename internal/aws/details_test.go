@@ -0,0 +1,37 @@
+package aws_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+func TestDetailsSummary(t *testing.T) {
+	docs := []string{
+		`{"name": "api", "id": "a", "start_time": 1700000000, "end_time": 1700000000.25, "fault": true,
+		  "http": {"request": {"method": "GET", "url": "https://example.com/orders/1"}, "response": {"status": 500}},
+		  "annotations": {"tenant": "acme"}}`,
+		`{"name": "orders", "id": "b", "parent_id": "c", "start_time": 1700000000.1, "end_time": 1700000000.5}`,
+	}
+	td := aws.TraceDetails{ID: "1-5759e988-bd862e3fe1be46a994272793"}
+	for _, doc := range docs {
+		var s aws.Segment
+		if err := json.Unmarshal([]byte(doc), &s); err != nil {
+			t.Fatal(err)
+		}
+		td.Segments = append(td.Segments, s)
+	}
+
+	summary := td.Summary()
+	if summary.ID() != string(td.ID) || summary.Method() != "GET" || summary.Path() != "/orders/1" || summary.Status() != 500 {
+		t.Errorf("Unexpected request %s %s %s %d", summary.ID(), summary.Method(), summary.Path(), summary.Status())
+	}
+	if summary.ResponseTime().Round(time.Millisecond) != 250*time.Millisecond || summary.Duration().Round(time.Millisecond) != 500*time.Millisecond {
+		t.Errorf("Unexpected times %s %s", summary.ResponseTime(), summary.Duration())
+	}
+	if !summary.HasFault() || summary.HasError() || summary.Annotation("tenant") != "acme" {
+		t.Errorf("Unexpected flags or annotations %+v", summary.Data)
+	}
+}
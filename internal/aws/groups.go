@@ -0,0 +1,152 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/xray"
+	"github.com/aws/aws-sdk-go-v2/service/xray/types"
+	"github.com/samber/lo"
+)
+
+// Group is an X-Ray group: a named filter expression that traces, the
+// service graph and insights can be scoped to.
+type Group struct {
+	Name                 string
+	ARN                  string
+	FilterExpression     string
+	InsightsEnabled      bool
+	NotificationsEnabled bool
+}
+
+func FetchGroups(ctx context.Context) ([]Group, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	groups := make([]Group, 0)
+	paginator := xray.NewGetGroupsPaginator(client, &xray.GetGroupsInput{})
+	for paginator.HasMorePages() {
+		resp, pageErr := paginator.NextPage(ctx)
+		if pageErr != nil {
+			return nil, fmt.Errorf("failed to get groups, %w", pageErr)
+		}
+		for _, g := range resp.Groups {
+			group := Group{
+				Name:             lo.FromPtr(g.GroupName),
+				ARN:              lo.FromPtr(g.GroupARN),
+				FilterExpression: lo.FromPtr(g.FilterExpression),
+			}
+			if ic := g.InsightsConfiguration; ic != nil {
+				group.InsightsEnabled = lo.FromPtr(ic.InsightsEnabled)
+				group.NotificationsEnabled = lo.FromPtr(ic.NotificationsEnabled)
+			}
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+func insightsConfiguration(g Group) *types.InsightsConfiguration {
+	return &types.InsightsConfiguration{
+		InsightsEnabled:      lo.ToPtr(g.InsightsEnabled),
+		NotificationsEnabled: lo.ToPtr(g.NotificationsEnabled),
+	}
+}
+
+func CreateGroup(ctx context.Context, g Group) error {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	_, err = client.CreateGroup(ctx, &xray.CreateGroupInput{
+		GroupName:             &g.Name,
+		FilterExpression:      &g.FilterExpression,
+		InsightsConfiguration: insightsConfiguration(g),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create group %s, %w", g.Name, err)
+	}
+	return nil
+}
+
+func UpdateGroup(ctx context.Context, g Group) error {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	_, err = client.UpdateGroup(ctx, &xray.UpdateGroupInput{
+		GroupName:             &g.Name,
+		FilterExpression:      &g.FilterExpression,
+		InsightsConfiguration: insightsConfiguration(g),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update group %s, %w", g.Name, err)
+	}
+	return nil
+}
+
+func DeleteGroup(ctx context.Context, name string) error {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	_, err = client.DeleteGroup(ctx, &xray.DeleteGroupInput{GroupName: &name})
+	if err != nil {
+		return fmt.Errorf("failed to delete group %s, %w", name, err)
+	}
+	return nil
+}
+
+type GroupChangeKind string
+
+const (
+	GroupCreate GroupChangeKind = "create"
+	GroupUpdate GroupChangeKind = "update"
+	GroupDelete GroupChangeKind = "delete"
+)
+
+type GroupChange struct {
+	Kind  GroupChangeKind
+	Group Group
+	// The group as it is now, for updates and deletes
+	Existing Group
+}
+
+// PlanGroupChanges works out the changes needed to make the existing groups
+// match the wanted ones. Groups that aren't wanted are only deleted when
+// prune is set, and the default group is never deleted.
+func PlanGroupChanges(wanted, existing []Group, prune bool) []GroupChange {
+	existingByName := lo.KeyBy(existing, func(g Group) string { return g.Name })
+	changes := make([]GroupChange, 0)
+	for _, g := range wanted {
+		current, ok := existingByName[g.Name]
+		switch {
+		case !ok:
+			changes = append(changes, GroupChange{Kind: GroupCreate, Group: g})
+		case current.FilterExpression != g.FilterExpression ||
+			current.InsightsEnabled != g.InsightsEnabled ||
+			current.NotificationsEnabled != g.NotificationsEnabled:
+			changes = append(changes, GroupChange{Kind: GroupUpdate, Group: g, Existing: current})
+		}
+	}
+	if !prune {
+		return changes
+	}
+	wantedNames := lo.SliceToMap(wanted, func(g Group) (string, bool) { return g.Name, true })
+	for _, g := range existing {
+		if g.Name != DefaultGroup && !wantedNames[g.Name] {
+			changes = append(changes, GroupChange{Kind: GroupDelete, Group: g, Existing: g})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,49 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+func TestPlanGroupChanges(t *testing.T) {
+	wanted := []aws.Group{
+		{Name: "api", FilterExpression: `service("api")`, InsightsEnabled: true},
+		{Name: "slow", FilterExpression: "responsetime > 5"},
+	}
+	existing := []aws.Group{
+		{Name: "Default"},
+		{Name: "api", FilterExpression: `service("api")`},
+		{Name: "old", FilterExpression: `service("old")`},
+	}
+
+	changes := aws.PlanGroupChanges(wanted, existing, false)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes without pruning, got %+v", changes)
+	}
+	if changes[0].Kind != aws.GroupUpdate || changes[0].Group.Name != "api" {
+		t.Errorf("Expected api to be updated to enable insights, got %+v", changes[0])
+	}
+	if changes[1].Kind != aws.GroupCreate || changes[1].Group.Name != "slow" {
+		t.Errorf("Expected slow to be created, got %+v", changes[1])
+	}
+
+	changes = aws.PlanGroupChanges(wanted, existing, true)
+	if len(changes) != 3 || changes[2].Kind != aws.GroupDelete || changes[2].Group.Name != "old" {
+		t.Errorf("Expected old, and not Default, to be deleted when pruning, got %+v", changes)
+	}
+}
+
+func TestQueryFilterExpressionIncludesGroup(t *testing.T) {
+	q := aws.NewTraceQuery().WithGroup(aws.Group{Name: "api", FilterExpression: `service("api")`})
+	if f := q.FilterExpression(); f != `service("api")` {
+		t.Errorf("Expected the group's filter alone, got %s", f)
+	}
+	q = q.WithFilter("fault")
+	if f := q.FilterExpression(); f != `(service("api")) AND (fault)` {
+		t.Errorf("Expected the group and query filters combined, got %s", f)
+	}
+	if q.GroupName() != "api" {
+		t.Errorf("Expected the group to be kept when the filter changes, got %s", q.GroupName())
+	}
+}
@@ -0,0 +1,222 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/xray"
+	"github.com/aws/aws-sdk-go-v2/service/xray/types"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+)
+
+// Insights are generated per group, and every account has a default group.
+const DefaultGroup = "Default"
+
+// RequestImpact counts the requests made while an insight was open.
+type RequestImpact struct {
+	Total  int64
+	OK     int64
+	Faults int64
+}
+
+func (r RequestImpact) FaultRate() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Faults) / float64(r.Total)
+}
+
+func parseRequestImpact(stats *types.RequestImpactStatistics) RequestImpact {
+	if stats == nil {
+		return RequestImpact{}
+	}
+	return RequestImpact{
+		Total:  lo.FromPtr(stats.TotalCount),
+		OK:     lo.FromPtr(stats.OkCount),
+		Faults: lo.FromPtr(stats.FaultCount),
+	}
+}
+
+// ServiceName identifies a service the way X-Ray filter expressions do.
+type ServiceName struct {
+	Name string
+	Type string
+}
+
+func (s ServiceName) String() string {
+	if s.Type == "" {
+		return s.Name
+	}
+	return fmt.Sprintf("%s (%s)", s.Name, s.Type)
+}
+
+func parseServiceName(id *types.ServiceId) ServiceName {
+	if id == nil {
+		return ServiceName{}
+	}
+	name := lo.FromPtr(id.Name)
+	if name == "" && len(id.Names) > 0 {
+		name = id.Names[0]
+	}
+	return ServiceName{Name: name, Type: lo.FromPtr(id.Type)}
+}
+
+func parseAnomalousServices(services []types.AnomalousService) []ServiceName {
+	return lo.Map(services, func(s types.AnomalousService, _ int) ServiceName {
+		return parseServiceName(s.ServiceId)
+	})
+}
+
+// Insight is an anomaly X-Ray detected in a group, such as a rise in faults.
+type Insight struct {
+	ID         string
+	GroupName  string
+	Summary    string
+	Active     bool
+	Categories []string
+	Start      time.Time
+	// Closed insights have an end time
+	End                  mo.Option[time.Time]
+	RootCauseService     ServiceName
+	ClientImpact         RequestImpact
+	RootCauseImpact      RequestImpact
+	TopAnomalousServices []ServiceName
+}
+
+// Window is the time range the insight covers, up to now if it is active.
+func (i Insight) Window() (time.Time, time.Time) {
+	return i.Start, i.End.OrElse(time.Now())
+}
+
+func parseInsight(s types.InsightSummary) Insight {
+	insight := Insight{
+		ID:        lo.FromPtr(s.InsightId),
+		GroupName: lo.FromPtr(s.GroupName),
+		Summary:   lo.FromPtr(s.Summary),
+		Active:    s.State == types.InsightStateActive,
+		Categories: lo.Map(s.Categories, func(c types.InsightCategory, _ int) string {
+			return strings.ToLower(string(c))
+		}),
+		Start:                lo.FromPtr(s.StartTime),
+		RootCauseService:     parseServiceName(s.RootCauseServiceId),
+		ClientImpact:         parseRequestImpact(s.ClientRequestImpactStatistics),
+		RootCauseImpact:      parseRequestImpact(s.RootCauseServiceRequestImpactStatistics),
+		TopAnomalousServices: parseAnomalousServices(s.TopAnomalousServices),
+	}
+	if s.EndTime != nil {
+		insight.End = mo.Some(*s.EndTime)
+	}
+	return insight
+}
+
+// InsightEvent is a change in an insight over its lifetime.
+type InsightEvent struct {
+	Time                 time.Time
+	Summary              string
+	ClientImpact         RequestImpact
+	RootCauseImpact      RequestImpact
+	TopAnomalousServices []ServiceName
+}
+
+func FetchInsights(ctx context.Context, query TraceQuery) ([]Insight, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	insights := make([]Insight, 0)
+	paginator := xray.NewGetInsightSummariesPaginator(client, &xray.GetInsightSummariesInput{
+		StartTime: &query.Start,
+		EndTime:   &query.End,
+		GroupName: lo.ToPtr(query.GroupName()),
+	})
+	for paginator.HasMorePages() {
+		resp, pageErr := paginator.NextPage(ctx)
+		if pageErr != nil {
+			return nil, fmt.Errorf("failed to get insight summaries, %w", pageErr)
+		}
+		for _, s := range resp.InsightSummaries {
+			insights = append(insights, parseInsight(s))
+		}
+	}
+	return insights, nil
+}
+
+func FetchInsightEvents(ctx context.Context, insightID string) ([]InsightEvent, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	events := make([]InsightEvent, 0)
+	paginator := xray.NewGetInsightEventsPaginator(client, &xray.GetInsightEventsInput{
+		InsightId: &insightID,
+	})
+	for paginator.HasMorePages() {
+		resp, pageErr := paginator.NextPage(ctx)
+		if pageErr != nil {
+			return nil, fmt.Errorf("failed to get insight events, %w", pageErr)
+		}
+		for _, e := range resp.InsightEvents {
+			events = append(events, InsightEvent{
+				Time:                 lo.FromPtr(e.EventTime),
+				Summary:              lo.FromPtr(e.Summary),
+				ClientImpact:         parseRequestImpact(e.ClientRequestImpactStatistics),
+				RootCauseImpact:      parseRequestImpact(e.RootCauseServiceRequestImpactStatistics),
+				TopAnomalousServices: parseAnomalousServices(e.TopAnomalousServices),
+			})
+		}
+	}
+	return events, nil
+}
+
+// FetchInsightImpactGraph gets the services affected by an insight. The
+// graph has no request statistics, only the services and their calls.
+func FetchInsightImpactGraph(ctx context.Context, insight Insight) (*ServiceGraph, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	start, end := insight.Window()
+	input := xray.GetInsightImpactGraphInput{
+		InsightId: &insight.ID,
+		StartTime: &start,
+		EndTime:   &end,
+	}
+	graph := ServiceGraph{}
+	for {
+		resp, respErr := client.GetInsightImpactGraph(ctx, &input)
+		if respErr != nil {
+			return nil, fmt.Errorf("failed to get insight impact graph, %w", respErr)
+		}
+		for _, service := range resp.Services {
+			graph.Services = append(graph.Services, parseImpactService(service))
+		}
+		if resp.NextToken == nil {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	graph.resolveEdgeNames()
+	return &graph, nil
+}
+
+func parseImpactService(service types.InsightImpactGraphService) ServiceNode {
+	name := parseServiceName(&types.ServiceId{Name: service.Name, Names: service.Names, Type: service.Type})
+	return ServiceNode{
+		ReferenceID: lo.FromPtr(service.ReferenceId),
+		Name:        name.Name,
+		Type:        name.Type,
+		Edges: lo.Map(service.Edges, func(edge types.InsightImpactGraphEdge, _ int) ServiceEdge {
+			return ServiceEdge{TargetID: lo.FromPtr(edge.ReferenceId)}
+		}),
+	}
+}
@@ -3,6 +3,8 @@ package aws
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -21,8 +23,17 @@ func (l LogData) IsEmpty() bool {
 
 type LogQueryID string
 
+// The most log events a search looks through, which is as many as Logs
+// Insights returns.
+const searchLogsLimit = 10000
+
+// LogsQuery is the Logs Insights query for a trace's log events.
+func LogsQuery(id TraceID) string {
+	return fmt.Sprintf("fields @log, @timestamp, @message | filter @message like \"%s\" | sort @timestamp desc", id)
+}
+
 func StartLogsQuery(ctx context.Context, logGroupNames []string, id TraceID) (*LogQueryID, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
 	}
@@ -30,7 +41,7 @@ func StartLogsQuery(ctx context.Context, logGroupNames []string, id TraceID) (*L
 
 	end := time.Now().Unix()
 	start := time.Now().Add(-24 * time.Hour).Unix()
-	query := fmt.Sprintf("fields @log, @timestamp, @message | filter @message like \"%s\" | sort @timestamp desc", id)
+	query := LogsQuery(id)
 	params := cloudwatchlogs.StartQueryInput{
 		QueryString:   &query,
 		StartTime:     &start,
@@ -46,8 +57,49 @@ func StartLogsQuery(ctx context.Context, logGroupNames []string, id TraceID) (*L
 	return &result, nil
 }
 
+// SearchLogs runs a Logs Insights query for log events containing a term,
+// waiting for it to finish, and returns their messages.
+func SearchLogs(ctx context.Context, logGroupNames []string, term string, start, end time.Time) ([]string, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := cloudwatchlogs.NewFromConfig(cfg)
+
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(term)
+	query := fmt.Sprintf("fields @message | filter @message like \"%s\" | sort @timestamp desc | limit %d", escaped, searchLogsLimit)
+	output, err := client.StartQuery(ctx, &cloudwatchlogs.StartQueryInput{
+		QueryString:   &query,
+		StartTime:     lo.ToPtr(start.Unix()),
+		EndTime:       lo.ToPtr(end.Unix()),
+		LogGroupNames: logGroupNames,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start query, %w", err)
+	}
+
+	for {
+		time.Sleep(time.Second)
+		results, resultsErr := client.GetQueryResults(ctx, &cloudwatchlogs.GetQueryResultsInput{QueryId: output.QueryId})
+		if resultsErr != nil {
+			return nil, fmt.Errorf("failed to get query results, %w", resultsErr)
+		}
+		switch results.Status {
+		case types.QueryStatusScheduled, types.QueryStatusRunning:
+			continue
+		case types.QueryStatusComplete:
+			return lo.FilterMap(results.Results, func(fields []types.ResultField, _ int) (string, bool) {
+				field, found := lo.Find(fields, func(f types.ResultField) bool { return lo.FromPtr(f.Field) == "@message" })
+				return lo.FromPtr(field.Value), found
+			}), nil
+		default:
+			return nil, fmt.Errorf("logs query %s", strings.ToLower(string(results.Status)))
+		}
+	}
+}
+
 func FetchLogs(ctx context.Context, queryID LogQueryID) (*LogData, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
 	}
@@ -65,7 +117,7 @@ func FetchLogs(ctx context.Context, queryID LogQueryID) (*LogData, error) {
 }
 
 func GetLogGroups(ctx context.Context) ([]string, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
 	}
@@ -84,3 +136,32 @@ func GetLogGroups(ctx context.Context) ([]string, error) {
 	})
 	return groups, nil
 }
+
+// MatchingLogGroups lists the log groups in the region matching any of the
+// regexps.
+func MatchingLogGroups(ctx context.Context, patterns []string) ([]string, error) {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile log group regexp %s, %w", pattern, err)
+		}
+		res[i] = re
+	}
+	if len(res) == 0 {
+		return []string{}, nil
+	}
+	groups, err := GetLogGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]string, 0)
+	for _, re := range res {
+		for _, lg := range groups {
+			if re.MatchString(lg) {
+				matching = append(matching, lg)
+			}
+		}
+	}
+	return matching, nil
+}
@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultQueryWindow is how far back traces are looked for, unless a fixed
+// time range is picked.
+const DefaultQueryWindow = 6 * time.Hour
+
+// TraceQuery scopes the traces we look at: a time range, an optional X-Ray
+// filter expression and an optional group.
+type TraceQuery struct {
+	Start  time.Time
+	End    time.Time
+	Filter string
+	// Trace summaries are scoped by the group's filter expression, the
+	// service graph and insights by its name
+	Group Group
+}
+
+func NewTraceQuery() TraceQuery {
+	end := time.Now()
+	return TraceQuery{
+		Start: end.Add(-DefaultQueryWindow),
+		End:   end,
+	}
+}
+
+// WithFilter returns a query with the given filter expression, covering the
+// same length of time but ending now.
+func (q TraceQuery) WithFilter(filter string) TraceQuery {
+	end := time.Now()
+	return TraceQuery{
+		Start:  end.Add(-q.End.Sub(q.Start)),
+		End:    end,
+		Filter: filter,
+		Group:  q.Group,
+	}
+}
+
+// WithWindow returns a query covering the given length of time up to now.
+func (q TraceQuery) WithWindow(d time.Duration) TraceQuery {
+	q.End = time.Now()
+	q.Start = q.End.Add(-d)
+	return q
+}
+
+// WithGroup returns a query scoped to the given group, covering the same
+// length of time but ending now.
+func (q TraceQuery) WithGroup(group Group) TraceQuery {
+	q = q.WithFilter(q.Filter)
+	q.Group = group
+	return q
+}
+
+// FilterExpression combines the group's filter expression with the query's.
+func (q TraceQuery) FilterExpression() string {
+	switch {
+	case q.Group.FilterExpression == "":
+		return q.Filter
+	case q.Filter == "":
+		return q.Group.FilterExpression
+	}
+	return fmt.Sprintf("(%s) AND (%s)", q.Group.FilterExpression, q.Filter)
+}
+
+// WithFilterClause returns a query with a clause added to its filter
+// expression.
+func (q TraceQuery) WithFilterClause(clause string) TraceQuery {
+	if q.Filter == "" {
+		return q.WithFilter(clause)
+	}
+	return q.WithFilter(fmt.Sprintf("(%s) AND %s", q.Filter, clause))
+}
+
+// AnnotationFilter is a filter expression clause matching traces with an
+// annotation value, e.g. annotation.tenant = "acme".
+func AnnotationFilter(key string, value any) string {
+	switch v := value.(type) {
+	case float64:
+		// %v would write large numbers in exponent form, which X-Ray rejects
+		return fmt.Sprintf("annotation.%s = %s", key, strconv.FormatFloat(v, 'f', -1, 64))
+	case bool, int, int64:
+		return fmt.Sprintf("annotation.%s = %v", key, v)
+	default:
+		return fmt.Sprintf("annotation.%s = %s", key, quoteFilterString(fmt.Sprint(v)))
+	}
+}
+
+// filterStringEscaper escapes only quotes and backslashes, as filter
+// expressions have no escapes for other characters.
+var filterStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func quoteFilterString(s string) string {
+	return `"` + filterStringEscaper.Replace(s) + `"`
+}
+
+func (q TraceQuery) GroupName() string {
+	if q.Group.Name == "" {
+		return DefaultGroup
+	}
+	return q.Group.Name
+}
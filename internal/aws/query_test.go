@@ -0,0 +1,38 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+func TestWithFilterClause(t *testing.T) {
+	q := aws.NewTraceQuery().WithFilterClause(aws.AnnotationFilter("tenant", `acme "eu"`))
+	if q.Filter != `annotation.tenant = "acme \"eu\""` {
+		t.Errorf("Expected a quoted string annotation clause, got %s", q.Filter)
+	}
+	q = q.WithFilterClause(aws.AnnotationFilter("retries", 3.0))
+	if q.Filter != `(annotation.tenant = "acme \"eu\"") AND annotation.retries = 3` {
+		t.Errorf("Expected the clauses combined, got %s", q.Filter)
+	}
+	if f := aws.AnnotationFilter("beta", true); f != "annotation.beta = true" {
+		t.Errorf("Expected a bare boolean, got %s", f)
+	}
+	if f := aws.AnnotationFilter("bytes", 1000000.0); f != "annotation.bytes = 1000000" {
+		t.Errorf("Expected a number without an exponent, got %s", f)
+	}
+	if f := aws.AnnotationFilter("city", "Zürich"); f != `annotation.city = "Zürich"` {
+		t.Errorf("Expected non-ASCII characters to be kept, got %s", f)
+	}
+}
+
+func TestWithWindow(t *testing.T) {
+	q := aws.NewTraceQuery().WithFilter("http.status = 500").WithWindow(30 * time.Minute)
+	if q.End.Sub(q.Start) != 30*time.Minute || time.Since(q.End) > time.Minute {
+		t.Errorf("Expected the last 30 minutes, got %s to %s", q.Start, q.End)
+	}
+	if q.Filter != "http.status = 500" {
+		t.Errorf("Expected the filter to be kept, got %s", q.Filter)
+	}
+}
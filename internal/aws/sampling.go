@@ -0,0 +1,293 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/xray"
+	"github.com/aws/aws-sdk-go-v2/service/xray/types"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+)
+
+// SamplingRule decides how many requests matching it are traced: the first
+// ReservoirSize each second, then FixedRate of the rest. The match fields are
+// globs, where * matches anything and ? a single character.
+type SamplingRule struct {
+	Name          string            `json:"name" yaml:"name"`
+	ARN           string            `json:"-" yaml:"-"`
+	Priority      int32             `json:"priority" yaml:"priority"`
+	FixedRate     float64           `json:"fixed_rate" yaml:"fixed_rate"`
+	ReservoirSize int32             `json:"reservoir_size" yaml:"reservoir_size"`
+	ServiceName   string            `json:"service_name,omitempty" yaml:"service_name,omitempty"`
+	ServiceType   string            `json:"service_type,omitempty" yaml:"service_type,omitempty"`
+	Host          string            `json:"host,omitempty" yaml:"host,omitempty"`
+	HTTPMethod    string            `json:"http_method,omitempty" yaml:"http_method,omitempty"`
+	URLPath       string            `json:"url_path,omitempty" yaml:"url_path,omitempty"`
+	ResourceARN   string            `json:"resource_arn,omitempty" yaml:"resource_arn,omitempty"`
+	Attributes    map[string]string `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// The rule X-Ray applies when no other rule matches.
+const DefaultSamplingRule = "Default"
+
+// WithDefaults fills in the match fields left empty in a rule file, which
+// X-Ray requires and which match everything.
+func (r SamplingRule) WithDefaults() SamplingRule {
+	for _, field := range []*string{
+		&r.ServiceName, &r.ServiceType, &r.Host, &r.HTTPMethod, &r.URLPath, &r.ResourceARN,
+	} {
+		if *field == "" {
+			*field = "*"
+		}
+	}
+	return r
+}
+
+// Differences lists the fields that differ from another version of the
+// rule, as "field: old -> new". Only the Default rule's rates can change, so
+// its other fields aren't compared.
+func (r SamplingRule) Differences(old SamplingRule) []string {
+	diffs := make([]string, 0)
+	diff := func(field string, o, n any) {
+		if fmt.Sprint(o) != fmt.Sprint(n) {
+			diffs = append(diffs, fmt.Sprintf("%s: %v -> %v", field, o, n))
+		}
+	}
+	diff("fixed_rate", old.FixedRate, r.FixedRate)
+	diff("reservoir_size", old.ReservoirSize, r.ReservoirSize)
+	if r.Name == DefaultSamplingRule {
+		return diffs
+	}
+	diff("priority", old.Priority, r.Priority)
+	diff("service_name", old.ServiceName, r.ServiceName)
+	diff("service_type", old.ServiceType, r.ServiceType)
+	diff("host", old.Host, r.Host)
+	diff("http_method", old.HTTPMethod, r.HTTPMethod)
+	diff("url_path", old.URLPath, r.URLPath)
+	diff("resource_arn", old.ResourceARN, r.ResourceARN)
+	if !maps.Equal(old.Attributes, r.Attributes) {
+		diff("attributes", old.Attributes, r.Attributes)
+	}
+	return diffs
+}
+
+// SamplingRequest describes a request to check against the sampling rules.
+// Fields left empty are unknown and match any pattern.
+type SamplingRequest struct {
+	ServiceName string
+	ServiceType string
+	Host        string
+	HTTPMethod  string
+	URLPath     string
+}
+
+// SamplingRequestFor describes the request that started a trace.
+func SamplingRequestFor(t TraceSummary) SamplingRequest {
+	req := SamplingRequest{
+		Host:       t.Host(),
+		HTTPMethod: t.Method(),
+		URLPath:    t.Path(),
+	}
+	if ep := t.Data.EntryPoint; ep != nil {
+		req.ServiceName = lo.FromPtr(ep.Name)
+		req.ServiceType = lo.FromPtr(ep.Type)
+	}
+	return req
+}
+
+func globMatches(pattern, value string) bool {
+	if value == "" || pattern == "" || pattern == "*" {
+		return true
+	}
+	re := "^" + strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern)) + "$"
+	// Rules match case-insensitively, like the X-Ray SDKs
+	matched, err := regexp.MatchString("(?i)"+re, value)
+	return err == nil && matched
+}
+
+// Matches reports whether the rule applies to the request. Rules with
+// attributes never match, since requests here don't have any.
+func (r SamplingRule) Matches(req SamplingRequest) bool {
+	return len(r.Attributes) == 0 &&
+		globMatches(r.ServiceName, req.ServiceName) &&
+		globMatches(r.ServiceType, req.ServiceType) &&
+		globMatches(r.Host, req.Host) &&
+		globMatches(r.HTTPMethod, req.HTTPMethod) &&
+		globMatches(r.URLPath, req.URLPath)
+}
+
+// SortSamplingRules puts rules in the order X-Ray evaluates them: by
+// priority, then by name.
+func SortSamplingRules(rules []SamplingRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		return rules[i].Name < rules[j].Name
+	})
+}
+
+// MatchSamplingRule finds the rule X-Ray would apply to a request.
+func MatchSamplingRule(rules []SamplingRule, req SamplingRequest) mo.Option[SamplingRule] {
+	sorted := append([]SamplingRule{}, rules...)
+	SortSamplingRules(sorted)
+	for _, r := range sorted {
+		if r.Matches(req) {
+			return mo.Some(r)
+		}
+	}
+	return mo.None[SamplingRule]()
+}
+
+// SamplingStatistics counts the requests a rule saw recently.
+type SamplingStatistics struct {
+	Requests int64
+	Sampled  int64
+	Borrowed int64
+	Since    time.Time
+}
+
+func (s SamplingStatistics) SampledRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Sampled) / float64(s.Requests)
+}
+
+func parseSamplingRule(r *types.SamplingRule) SamplingRule {
+	return SamplingRule{
+		Name:          lo.FromPtr(r.RuleName),
+		ARN:           lo.FromPtr(r.RuleARN),
+		Priority:      lo.FromPtr(r.Priority),
+		FixedRate:     r.FixedRate,
+		ReservoirSize: r.ReservoirSize,
+		ServiceName:   lo.FromPtr(r.ServiceName),
+		ServiceType:   lo.FromPtr(r.ServiceType),
+		Host:          lo.FromPtr(r.Host),
+		HTTPMethod:    lo.FromPtr(r.HTTPMethod),
+		URLPath:       lo.FromPtr(r.URLPath),
+		ResourceARN:   lo.FromPtr(r.ResourceARN),
+		Attributes:    r.Attributes,
+	}
+}
+
+func FetchSamplingRules(ctx context.Context) ([]SamplingRule, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	rules := make([]SamplingRule, 0)
+	paginator := xray.NewGetSamplingRulesPaginator(client, &xray.GetSamplingRulesInput{})
+	for paginator.HasMorePages() {
+		resp, pageErr := paginator.NextPage(ctx)
+		if pageErr != nil {
+			return nil, fmt.Errorf("failed to get sampling rules, %w", pageErr)
+		}
+		for _, record := range resp.SamplingRuleRecords {
+			if record.SamplingRule != nil {
+				rules = append(rules, parseSamplingRule(record.SamplingRule))
+			}
+		}
+	}
+	SortSamplingRules(rules)
+	return rules, nil
+}
+
+// FetchSamplingStatistics gets the recent statistics for each rule, by
+// rule name.
+func FetchSamplingStatistics(ctx context.Context) (map[string]SamplingStatistics, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	stats := map[string]SamplingStatistics{}
+	paginator := xray.NewGetSamplingStatisticSummariesPaginator(client, &xray.GetSamplingStatisticSummariesInput{})
+	for paginator.HasMorePages() {
+		resp, pageErr := paginator.NextPage(ctx)
+		if pageErr != nil {
+			return nil, fmt.Errorf("failed to get sampling statistics, %w", pageErr)
+		}
+		for _, summary := range resp.SamplingStatisticSummaries {
+			name := lo.FromPtr(summary.RuleName)
+			s := stats[name]
+			s.Requests += int64(summary.RequestCount)
+			s.Sampled += int64(summary.SampledCount)
+			s.Borrowed += int64(summary.BorrowCount)
+			if ts := lo.FromPtr(summary.Timestamp); s.Since.IsZero() || ts.Before(s.Since) {
+				s.Since = ts
+			}
+			stats[name] = s
+		}
+	}
+	return stats, nil
+}
+
+func CreateSamplingRule(ctx context.Context, r SamplingRule) error {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	_, err = client.CreateSamplingRule(ctx, &xray.CreateSamplingRuleInput{
+		SamplingRule: &types.SamplingRule{
+			RuleName:      &r.Name,
+			Priority:      &r.Priority,
+			FixedRate:     r.FixedRate,
+			ReservoirSize: r.ReservoirSize,
+			ServiceName:   &r.ServiceName,
+			ServiceType:   &r.ServiceType,
+			Host:          &r.Host,
+			HTTPMethod:    &r.HTTPMethod,
+			URLPath:       &r.URLPath,
+			ResourceARN:   &r.ResourceARN,
+			Attributes:    r.Attributes,
+			Version:       lo.ToPtr(int32(1)),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create sampling rule %s, %w", r.Name, err)
+	}
+	return nil
+}
+
+func UpdateSamplingRule(ctx context.Context, r SamplingRule) error {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	update := &types.SamplingRuleUpdate{
+		RuleName:      &r.Name,
+		FixedRate:     &r.FixedRate,
+		ReservoirSize: &r.ReservoirSize,
+	}
+	// Only the Default rule's rates can change
+	if r.Name != DefaultSamplingRule {
+		update.Priority = &r.Priority
+		update.ServiceName = &r.ServiceName
+		update.ServiceType = &r.ServiceType
+		update.Host = &r.Host
+		update.HTTPMethod = &r.HTTPMethod
+		update.URLPath = &r.URLPath
+		update.ResourceARN = &r.ResourceARN
+		update.Attributes = r.Attributes
+	}
+	_, err = client.UpdateSamplingRule(ctx, &xray.UpdateSamplingRuleInput{SamplingRuleUpdate: update})
+	if err != nil {
+		return fmt.Errorf("failed to update sampling rule %s, %w", r.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+func TestMatchSamplingRule(t *testing.T) {
+	rules := []aws.SamplingRule{
+		aws.SamplingRule{Name: "Default", Priority: 10000, FixedRate: 0.05, ReservoirSize: 1}.WithDefaults(),
+		aws.SamplingRule{Name: "checkout", Priority: 10, FixedRate: 1, URLPath: "/checkout*", HTTPMethod: "POST"}.WithDefaults(),
+		aws.SamplingRule{Name: "health", Priority: 1, URLPath: "/health"}.WithDefaults(),
+		aws.SamplingRule{Name: "tenant", Priority: 2, Attributes: map[string]string{"tenant": "a"}}.WithDefaults(),
+	}
+
+	cases := map[string]aws.SamplingRequest{
+		"checkout": {HTTPMethod: "post", URLPath: "/checkout/123"},
+		"health":   {HTTPMethod: "GET", URLPath: "/health"},
+		"Default":  {HTTPMethod: "GET", URLPath: "/checkout/123"},
+	}
+	for expected, req := range cases {
+		rule, ok := aws.MatchSamplingRule(rules, req).Get()
+		if !ok || rule.Name != expected {
+			t.Errorf("Expected %+v to match %s, got %+v", req, expected, rule)
+		}
+	}
+}
+
+func TestSamplingRuleDifferences(t *testing.T) {
+	old := aws.SamplingRule{Name: "checkout", Priority: 10, FixedRate: 0.1}.WithDefaults()
+	updated := old
+	updated.FixedRate = 0.5
+	updated.URLPath = "/checkout*"
+
+	diffs := updated.Differences(old)
+	if len(diffs) != 2 || diffs[0] != "fixed_rate: 0.1 -> 0.5" || diffs[1] != "url_path: * -> /checkout*" {
+		t.Errorf("Unexpected differences: %v", diffs)
+	}
+}
+
+func TestDefaultSamplingRuleDifferences(t *testing.T) {
+	old := aws.SamplingRule{Name: aws.DefaultSamplingRule, Priority: 10000, FixedRate: 0.05, ReservoirSize: 1}.WithDefaults()
+	updated := aws.SamplingRule{Name: aws.DefaultSamplingRule, FixedRate: 0.1, ReservoirSize: 1}
+
+	diffs := updated.Differences(old)
+	if len(diffs) != 1 || diffs[0] != "fixed_rate: 0.05 -> 0.1" {
+		t.Errorf("Expected only the rates to be compared, got %v", diffs)
+	}
+}
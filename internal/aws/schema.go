@@ -56,6 +56,22 @@ type Segment struct {
 
 	// Not part of the schema but found in practice
 	SQL mo.Option[SQL] `json:"sql,omitempty"`
+
+	// The whole document, including its subsegments and any fields not
+	// parsed above, for jq queries
+	Document map[string]any `json:"-"`
+}
+
+func (s *Segment) UnmarshalJSON(b []byte) error {
+	type segment Segment
+	if err := json.Unmarshal(b, (*segment)(s)); err != nil {
+		return err
+	}
+	return json.Unmarshal(b, &s.Document)
+}
+
+func (s Segment) Duration() time.Duration {
+	return s.EndTime.Time().Sub(s.StartTime.Time())
 }
 
 type SubSegment struct {
@@ -88,6 +104,10 @@ type SubSegment struct {
 	SQL mo.Option[SQL] `json:"sql,omitempty"`
 }
 
+func (s SubSegment) Duration() time.Duration {
+	return s.EndTime.Time().Sub(s.StartTime.Time())
+}
+
 type SegmentHTTP struct {
 	Request  SegmentHTTPRequest  `json:"request,omitempty"`
 	Response SegmentHTTPResponse `json:"response,omitempty"`
@@ -0,0 +1,198 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/xray"
+	"github.com/aws/aws-sdk-go-v2/service/xray/types"
+	"github.com/samber/lo"
+)
+
+type ServiceGraph struct {
+	Services []ServiceNode
+}
+
+type ServiceNode struct {
+	ReferenceID int32
+	Name        string
+	Type        string
+	Root        bool
+	Stats       RequestStats
+	Edges       []ServiceEdge
+}
+
+type ServiceEdge struct {
+	TargetID   int32
+	TargetName string
+	Stats      RequestStats
+}
+
+type HistogramBucket struct {
+	// Response time in seconds
+	Value float64
+	Count int32
+}
+
+// RequestStats summarises the requests to a service or along an edge.
+type RequestStats struct {
+	Total     int64
+	OK        int64
+	Errors    int64
+	Faults    int64
+	Throttles int64
+	// Sum of all response times, in seconds
+	TotalResponseTime float64
+	Histogram         []HistogramBucket
+}
+
+func (s RequestStats) rate(n int64) float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(n) / float64(s.Total)
+}
+
+func (s RequestStats) ErrorRate() float64 {
+	return s.rate(s.Errors)
+}
+
+func (s RequestStats) FaultRate() float64 {
+	return s.rate(s.Faults)
+}
+
+func (s RequestStats) ThrottleRate() float64 {
+	return s.rate(s.Throttles)
+}
+
+func (s RequestStats) MeanResponseTime() time.Duration {
+	if s.Total == 0 {
+		return 0
+	}
+	return time.Duration(s.TotalResponseTime / float64(s.Total) * float64(time.Second))
+}
+
+// Percentile estimates a response time percentile (0-100) from the histogram.
+func (s RequestStats) Percentile(p float64) time.Duration {
+	var total int64
+	for _, b := range s.Histogram {
+		total += int64(b.Count)
+	}
+	if total == 0 {
+		return 0
+	}
+	threshold := p / 100 * float64(total)
+	var cumulative int64
+	for _, b := range s.Histogram {
+		cumulative += int64(b.Count)
+		if float64(cumulative) >= threshold {
+			return time.Duration(b.Value * float64(time.Second))
+		}
+	}
+	last := s.Histogram[len(s.Histogram)-1]
+	return time.Duration(last.Value * float64(time.Second))
+}
+
+func parseHistogram(entries []types.HistogramEntry) []HistogramBucket {
+	buckets := lo.Map(entries, func(e types.HistogramEntry, _ int) HistogramBucket {
+		return HistogramBucket{Value: e.Value, Count: e.Count}
+	})
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Value < buckets[j].Value
+	})
+	return buckets
+}
+
+func parseRequestStats(
+	ok, total *int64,
+	errs *types.ErrorStatistics,
+	faults *types.FaultStatistics,
+	totalResponseTime *float64,
+	histogram []types.HistogramEntry,
+) RequestStats {
+	stats := RequestStats{
+		Total:             lo.FromPtr(total),
+		OK:                lo.FromPtr(ok),
+		TotalResponseTime: lo.FromPtr(totalResponseTime),
+		Histogram:         parseHistogram(histogram),
+	}
+	if errs != nil {
+		stats.Errors = lo.FromPtr(errs.TotalCount)
+		stats.Throttles = lo.FromPtr(errs.ThrottleCount)
+	}
+	if faults != nil {
+		stats.Faults = lo.FromPtr(faults.TotalCount)
+	}
+	return stats
+}
+
+func parseService(service types.Service) ServiceNode {
+	node := ServiceNode{
+		ReferenceID: lo.FromPtr(service.ReferenceId),
+		Name:        lo.FromPtr(service.Name),
+		Type:        lo.FromPtr(service.Type),
+		Root:        lo.FromPtr(service.Root),
+	}
+	if st := service.SummaryStatistics; st != nil {
+		node.Stats = parseRequestStats(
+			st.OkCount, st.TotalCount, st.ErrorStatistics, st.FaultStatistics,
+			st.TotalResponseTime, service.ResponseTimeHistogram)
+	}
+	node.Edges = lo.Map(service.Edges, func(edge types.Edge, _ int) ServiceEdge {
+		e := ServiceEdge{TargetID: lo.FromPtr(edge.ReferenceId)}
+		if st := edge.SummaryStatistics; st != nil {
+			e.Stats = parseRequestStats(
+				st.OkCount, st.TotalCount, st.ErrorStatistics, st.FaultStatistics,
+				st.TotalResponseTime, edge.ResponseTimeHistogram)
+		}
+		return e
+	})
+	return node
+}
+
+func FetchServiceGraph(ctx context.Context, query TraceQuery) (*ServiceGraph, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
+	}
+	client := xray.NewFromConfig(cfg)
+
+	graph := ServiceGraph{}
+	input := xray.GetServiceGraphInput{
+		StartTime: &query.Start,
+		EndTime:   &query.End,
+	}
+	if query.Group.Name != "" {
+		input.GroupName = &query.Group.Name
+	}
+	paginator := xray.NewGetServiceGraphPaginator(client, &input)
+	for paginator.HasMorePages() {
+		resp, pageErr := paginator.NextPage(ctx)
+		if pageErr != nil {
+			return nil, fmt.Errorf("failed to get service graph, %w", pageErr)
+		}
+		for _, service := range resp.Services {
+			graph.Services = append(graph.Services, parseService(service))
+		}
+	}
+
+	graph.resolveEdgeNames()
+	return &graph, nil
+}
+
+// Edges only carry reference IDs, so resolve them to names.
+func (g *ServiceGraph) resolveEdgeNames() {
+	names := make(map[int32]string, len(g.Services))
+	for _, node := range g.Services {
+		names[node.ReferenceID] = node.Name
+	}
+	for i := range g.Services {
+		for j := range g.Services[i].Edges {
+			edge := &g.Services[i].Edges[j]
+			edge.TargetName = names[edge.TargetID]
+		}
+	}
+}
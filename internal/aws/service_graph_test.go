@@ -0,0 +1,34 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+func TestRequestStatsPercentile(t *testing.T) {
+	stats := aws.RequestStats{
+		Total: 100,
+		Histogram: []aws.HistogramBucket{
+			{Value: 0.01, Count: 50},
+			{Value: 0.05, Count: 40},
+			{Value: 0.5, Count: 9},
+			{Value: 2, Count: 1},
+		},
+	}
+	cases := map[float64]time.Duration{
+		50:  10 * time.Millisecond,
+		90:  50 * time.Millisecond,
+		99:  500 * time.Millisecond,
+		100: 2 * time.Second,
+	}
+	for p, want := range cases {
+		if got := stats.Percentile(p); got != want {
+			t.Errorf("p%v: expected %v, got %v", p, want, got)
+		}
+	}
+	if got := (aws.RequestStats{}).Percentile(50); got != 0 {
+		t.Errorf("Expected 0 for an empty histogram, got %v", got)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/url"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -24,22 +26,29 @@ type TraceSummary struct {
 }
 
 func (t TraceSummary) Title() string {
-	startTime := t.Data.StartTime.Format("01-02 15:04:05")
+	startTime := t.StartTime().Format("01-02 15:04:05")
 	if t.Data.Http == nil {
-		return "(no trace http data)"
+		return fmt.Sprintf(
+			"%s %v %s %vms %s",
+			t.ID(),
+			startTime,
+			t.StatusText(),
+			t.ResponseTime().Milliseconds(),
+			t.Operation(),
+		)
 	}
 	ip := "(no client ip)"
 	if t.Data.Http.ClientIp != nil {
 		ip = *t.Data.Http.ClientIp
 	}
 	title := fmt.Sprintf(
-		"%s %v %s (%d) %s %vms %s",
-		*t.Data.Id,
+		"%s %v %s (%s) %s %vms %s",
+		t.ID(),
 		startTime,
 		ip,
-		*t.Data.Http.HttpStatus,
-		*t.Data.Http.HttpMethod,
-		*t.Data.ResponseTime*1000,
+		t.StatusText(),
+		t.Method(),
+		t.ResponseTime().Milliseconds(),
 		t.Path(),
 	)
 	return title
@@ -49,7 +58,11 @@ func (t TraceSummary) ID() string {
 	return *t.Data.Id
 }
 
+// Path is the path of the request URL, or "" for traces without HTTP data.
 func (t TraceSummary) Path() string {
+	if t.Data.Http == nil || t.Data.Http.HttpURL == nil {
+		return ""
+	}
 	u, err := url.Parse(*t.Data.Http.HttpURL)
 	if err != nil {
 		return ""
@@ -57,43 +70,215 @@ func (t TraceSummary) Path() string {
 	return u.Path
 }
 
+func (t TraceSummary) Host() string {
+	if t.Data.Http == nil || t.Data.Http.HttpURL == nil {
+		return ""
+	}
+	u, err := url.Parse(*t.Data.Http.HttpURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// EntryPoint describes the service the trace started at, e.g.
+// "orders-consumer (AWS::Lambda::Function)".
+func (t TraceSummary) EntryPoint() string {
+	if t.Data.EntryPoint == nil {
+		return ""
+	}
+	name := lo.FromPtr(t.Data.EntryPoint.Name)
+	if t.Data.EntryPoint.Type == nil {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, *t.Data.EntryPoint.Type)
+}
+
+// Operation describes what the trace did: the request path for HTTP traces
+// and the entry point for anything else, such as queue consumers.
+func (t TraceSummary) Operation() string {
+	if t.Data.Http != nil && t.Data.Http.HttpURL != nil {
+		return t.Path()
+	}
+	if entryPoint := t.EntryPoint(); entryPoint != "" {
+		return entryPoint
+	}
+	return "(no trace http data)"
+}
+
 func (t TraceSummary) FilterValue() string {
 	return fmt.Sprintf(
-		"%s %d %s %s",
-		*t.Data.Id,
-		*t.Data.Http.HttpStatus,
-		*t.Data.Http.HttpMethod,
-		t.Path(),
+		"%s %s %s %s",
+		t.ID(),
+		t.StatusText(),
+		t.Method(),
+		t.Operation(),
 	)
 }
 
+func (t TraceSummary) StartTime() time.Time {
+	return lo.FromPtr(t.Data.StartTime)
+}
+
+// ResponseTime is the time between the start of the request and the
+// response being sent.
+func (t TraceSummary) ResponseTime() time.Duration {
+	return time.Duration(lo.FromPtr(t.Data.ResponseTime) * float64(time.Second))
+}
+
+// Duration is the time between the start of the first segment and the end of
+// the last one, which includes any work done after responding.
+func (t TraceSummary) Duration() time.Duration {
+	return time.Duration(lo.FromPtr(t.Data.Duration) * float64(time.Second))
+}
+
+func (t TraceSummary) ClientIP() string {
+	if t.Data.Http == nil {
+		return ""
+	}
+	return lo.FromPtr(t.Data.Http.ClientIp)
+}
+
+func (t TraceSummary) Method() string {
+	if t.Data.Http == nil {
+		return ""
+	}
+	return lo.FromPtr(t.Data.Http.HttpMethod)
+}
+
+// Status is the HTTP response status, or 0 for traces without one.
+func (t TraceSummary) Status() int {
+	if t.Data.Http == nil {
+		return 0
+	}
+	return int(lo.FromPtr(t.Data.Http.HttpStatus))
+}
+
+// ServiceNames lists the services the trace passed through.
+func (t TraceSummary) ServiceNames() []string {
+	names := lo.FilterMap(t.Data.ServiceIds, func(s types.ServiceId, _ int) (string, bool) {
+		return lo.FromPtr(s.Name), s.Name != nil
+	})
+	return lo.Uniq(names)
+}
+
+func (t TraceSummary) UserNames() []string {
+	names := lo.FilterMap(t.Data.Users, func(u types.TraceUser, _ int) (string, bool) {
+		return lo.FromPtr(u.UserName), u.UserName != nil
+	})
+	return lo.Uniq(names)
+}
+
+// Annotation returns the values recorded for an annotation key, joined with
+// commas when services recorded different values.
+func (t TraceSummary) Annotation(key string) string {
+	values := lo.Map(t.Data.Annotations[key], func(v types.ValueWithServiceIds, _ int) string {
+		return annotationValueString(v.AnnotationValue)
+	})
+	return strings.Join(lo.Uniq(values), ",")
+}
+
+func annotationValueString(v types.AnnotationValue) string {
+	switch v := v.(type) {
+	case *types.AnnotationValueMemberStringValue:
+		return v.Value
+	case *types.AnnotationValueMemberNumberValue:
+		return fmt.Sprint(v.Value)
+	case *types.AnnotationValueMemberBooleanValue:
+		return fmt.Sprint(v.Value)
+	}
+	return ""
+}
+
+// RootCause describes the first fault or error root cause X-Ray identified,
+// as "service: exception".
+func (t TraceSummary) RootCause() string {
+	for _, cause := range t.Data.FaultRootCauses {
+		for _, service := range cause.Services {
+			for _, entity := range service.EntityPath {
+				for _, ex := range entity.Exceptions {
+					return rootCauseString(service.Name, ex)
+				}
+			}
+		}
+	}
+	for _, cause := range t.Data.ErrorRootCauses {
+		for _, service := range cause.Services {
+			for _, entity := range service.EntityPath {
+				for _, ex := range entity.Exceptions {
+					return rootCauseString(service.Name, ex)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func rootCauseString(service *string, ex types.RootCauseException) string {
+	s := lo.FromPtr(ex.Name)
+	if ex.Message != nil {
+		s += ": " + *ex.Message
+	}
+	if service != nil {
+		s = *service + ": " + s
+	}
+	return s
+}
+
+// StatusText is the HTTP status, or for traces without one, how X-Ray
+// classified the trace.
+func (t TraceSummary) StatusText() string {
+	if status := t.Status(); status != 0 {
+		return strconv.Itoa(status)
+	}
+	switch {
+	case t.HasFault():
+		return "fault"
+	case t.HasThrottle():
+		return "throttle"
+	case t.HasError():
+		return "error"
+	}
+	return "-"
+}
+
+// HasError is true when X-Ray flagged a client error (4xx) anywhere in the
+// trace. Throttles are also errors.
 func (t TraceSummary) HasError() bool {
-	status := *t.Data.Http.HttpStatus
-	return status >= 400 && status < 500
+	return lo.FromPtr(t.Data.HasError) || len(t.Data.ErrorRootCauses) > 0
 }
 
+// HasFault is true when X-Ray flagged a server fault (5xx) anywhere in the
+// trace.
 func (t TraceSummary) HasFault() bool {
-	status := *t.Data.Http.HttpStatus
-	return status >= 500 && status < 600
+	return lo.FromPtr(t.Data.HasFault) || len(t.Data.FaultRootCauses) > 0
+}
+
+// HasThrottle is true when a request in the trace was throttled (429).
+func (t TraceSummary) HasThrottle() bool {
+	return lo.FromPtr(t.Data.HasThrottle)
 }
 
 func FetchTraceSummaries(
 	ctx context.Context,
+	query TraceQuery,
 	nextToken mo.Option[string],
 ) (*SummaryData, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := config.LoadDefaultConfig(ctx, withRegion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS configuration, %w", err)
 	}
 	client := xray.NewFromConfig(cfg)
 
-	start := time.Now().Add(-6 * time.Hour)
-	end := time.Now()
-	resp, err := client.GetTraceSummaries(ctx, &xray.GetTraceSummariesInput{
-		EndTime:   &end,
-		StartTime: &start,
+	input := xray.GetTraceSummariesInput{
+		EndTime:   &query.End,
+		StartTime: &query.Start,
 		NextToken: nextToken.ToPointer(),
-	})
+	}
+	if filter := query.FilterExpression(); filter != "" {
+		input.FilterExpression = &filter
+	}
+	resp, err := client.GetTraceSummaries(ctx, &input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trace summaries, %w", err)
 	}
@@ -0,0 +1,61 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/xray/types"
+	"github.com/samber/lo"
+	"github.com/zopu/tracey/internal/aws"
+)
+
+func TestClassificationUsesXRayFlags(t *testing.T) {
+	ts := aws.TraceSummary{
+		Data: types.TraceSummary{
+			Id:          lo.ToPtr("1-5f84c7a1-e5b6a6b7c8d9e0f1a2b3c4d5"),
+			HasError:    lo.ToPtr(true),
+			HasThrottle: lo.ToPtr(true),
+			Http: &types.Http{
+				HttpStatus: lo.ToPtr(int32(200)),
+			},
+		},
+	}
+	if !ts.HasError() || !ts.HasThrottle() || ts.HasFault() {
+		t.Errorf("Expected error and throttle but not fault, got error=%v throttle=%v fault=%v",
+			ts.HasError(), ts.HasThrottle(), ts.HasFault())
+	}
+
+	ts = aws.TraceSummary{
+		Data: types.TraceSummary{
+			Id:              lo.ToPtr("1-5f84c7a1-e5b6a6b7c8d9e0f1a2b3c4d5"),
+			FaultRootCauses: []types.FaultRootCause{{}},
+		},
+	}
+	if !ts.HasFault() {
+		t.Errorf("Expected a fault from the fault root causes")
+	}
+}
+
+func TestNonHTTPTrace(t *testing.T) {
+	ts := aws.TraceSummary{
+		Data: types.TraceSummary{
+			Id:       lo.ToPtr("1-5f84c7a1-e5b6a6b7c8d9e0f1a2b3c4d5"),
+			HasFault: lo.ToPtr(true),
+			EntryPoint: &types.ServiceId{
+				Name: lo.ToPtr("orders-consumer"),
+				Type: lo.ToPtr("AWS::Lambda::Function"),
+			},
+		},
+	}
+	if ts.Path() != "" {
+		t.Errorf("Expected no path, got %q", ts.Path())
+	}
+	if got := ts.Operation(); got != "orders-consumer (AWS::Lambda::Function)" {
+		t.Errorf("Expected the entry point as the operation, got %q", got)
+	}
+	if got := ts.StatusText(); got != "fault" {
+		t.Errorf("Expected status text \"fault\", got %q", got)
+	}
+	// These used to dereference the missing HTTP data
+	_ = ts.Title()
+	_ = ts.FilterValue()
+}
@@ -1,3 +1,29 @@
 package aws
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
 type TraceID string
+
+// TraceIDPattern matches X-Ray trace IDs.
+var TraceIDPattern = regexp.MustCompile(`1-[0-9a-fA-F]{8}-[0-9a-fA-F]{24}`)
+
+// ParseTraceID finds a trace ID in a bare ID, an X-Amzn-Trace-Id header
+// (Root=...;Parent=...;Sampled=...) or a console URL.
+func ParseTraceID(s string) (TraceID, error) {
+	s = strings.TrimSpace(s)
+	for _, field := range strings.Split(s, ";") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(field), "="); ok && strings.EqualFold(key, "Root") {
+			s = value
+			break
+		}
+	}
+	id := TraceIDPattern.FindString(s)
+	if id == "" {
+		return "", fmt.Errorf("no trace ID in %q", s)
+	}
+	return TraceID(strings.ToLower(id)), nil
+}
@@ -0,0 +1,27 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
+func TestParseTraceID(t *testing.T) {
+	const id = "1-5759e988-bd862e3fe1be46a994272793"
+	inputs := []string{
+		id,
+		"  " + id + "\n",
+		"Root=" + id + ";Parent=53995c3f42cd8ad8;Sampled=1",
+		"Self=1-67891234-12456789abcdef012345678;Root=" + id,
+		"https://eu-west-1.console.aws.amazon.com/cloudwatch/home?region=eu-west-1#xray:traces/" + id,
+	}
+	for _, input := range inputs {
+		got, err := aws.ParseTraceID(input)
+		if err != nil || got != id {
+			t.Errorf("ParseTraceID(%q) = %s, %v", input, got, err)
+		}
+	}
+	if _, err := aws.ParseTraceID("Parent=53995c3f42cd8ad8"); err == nil {
+		t.Error("Expected an error for a header without a trace ID")
+	}
+}
@@ -0,0 +1,153 @@
+// Package bookmarks keeps notes on traces worth coming back to, in a local
+// JSON file.
+package bookmarks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/aws"
+)
+
+type Bookmark struct {
+	TraceID string    `json:"trace_id"`
+	Note    string    `json:"note,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Added   time.Time `json:"added"`
+
+	// Details of the trace, when it was in the trace list
+	StartTime    time.Time `json:"start_time,omitempty"`
+	Method       string    `json:"method,omitempty"`
+	Path         string    `json:"path,omitempty"`
+	Status       int       `json:"status,omitempty"`
+	ResponseTime float64   `json:"response_time,omitempty"`
+}
+
+// New bookmarks a trace, keeping the details shown in the trace list.
+func New(t aws.TraceSummary) Bookmark {
+	return Bookmark{
+		TraceID:      t.ID(),
+		Added:        time.Now(),
+		StartTime:    t.StartTime(),
+		Method:       t.Method(),
+		Path:         t.Operation(),
+		Status:       t.Status(),
+		ResponseTime: t.ResponseTime().Seconds(),
+	}
+}
+
+func (b Bookmark) HasTag(tag string) bool {
+	return slices.Contains(b.Tags, tag)
+}
+
+// ParseTags splits a comma or space separated list of tags.
+func ParseTags(s string) []string {
+	tags := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// Bookmarks is the list of bookmarks in a file, oldest first.
+type Bookmarks struct {
+	Path  string
+	Items []Bookmark
+}
+
+// DefaultPath is bookmarks.json in $XDG_DATA_HOME/tracey, or
+// ~/.local/share/tracey.
+func DefaultPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error finding home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "tracey", "bookmarks.json"), nil
+}
+
+// Load reads bookmarks from a file. A missing file has no bookmarks.
+func Load(path string) (*Bookmarks, error) {
+	b := &Bookmarks{Path: path, Items: []Bookmark{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading bookmarks: %w", err)
+	}
+	if err = json.Unmarshal(data, &b.Items); err != nil {
+		return nil, fmt.Errorf("error parsing bookmarks %s: %w", path, err)
+	}
+	return b, nil
+}
+
+func (b Bookmarks) Save() error {
+	data, err := json.MarshalIndent(b.Items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding bookmarks: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(b.Path), 0o700); err != nil {
+		return fmt.Errorf("error creating bookmarks directory: %w", err)
+	}
+	// Write a temporary file and rename it over the old one, so a failed
+	// write can't leave the bookmarks half written
+	tmp, err := os.CreateTemp(filepath.Dir(b.Path), filepath.Base(b.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error writing bookmarks: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing bookmarks: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("error writing bookmarks: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), b.Path); err != nil {
+		return fmt.Errorf("error writing bookmarks: %w", err)
+	}
+	return nil
+}
+
+func (b Bookmarks) Get(traceID string) mo.Option[Bookmark] {
+	i := slices.IndexFunc(b.Items, func(bm Bookmark) bool { return bm.TraceID == traceID })
+	if i < 0 {
+		return mo.None[Bookmark]()
+	}
+	return mo.Some(b.Items[i])
+}
+
+// Set adds a bookmark, or replaces the one for the same trace.
+func (b *Bookmarks) Set(bm Bookmark) {
+	i := slices.IndexFunc(b.Items, func(existing Bookmark) bool { return existing.TraceID == bm.TraceID })
+	if i < 0 {
+		b.Items = append(b.Items, bm)
+		return
+	}
+	b.Items[i] = bm
+}
+
+func (b *Bookmarks) Remove(traceID string) {
+	b.Items = slices.DeleteFunc(b.Items, func(bm Bookmark) bool { return bm.TraceID == traceID })
+}
+
+// IDs is the set of bookmarked trace IDs.
+func (b Bookmarks) IDs() map[string]bool {
+	ids := make(map[string]bool, len(b.Items))
+	for _, bm := range b.Items {
+		ids[bm.TraceID] = true
+	}
+	return ids
+}
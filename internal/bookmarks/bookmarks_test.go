@@ -0,0 +1,55 @@
+package bookmarks_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zopu/tracey/internal/bookmarks"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tracey", "bookmarks.json")
+	b, err := bookmarks.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Items) != 0 {
+		t.Fatalf("expected no bookmarks, got %v", b.Items)
+	}
+
+	b.Set(bookmarks.Bookmark{TraceID: "1-a", Note: "slow"})
+	b.Set(bookmarks.Bookmark{TraceID: "1-b"})
+	b.Set(bookmarks.Bookmark{TraceID: "1-a", Note: "slow checkout", Tags: bookmarks.ParseTags("incident, checkout")})
+	b.Remove("1-b")
+	if err = b.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := bookmarks.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bm, ok := loaded.Get("1-a").Get()
+	if !ok || len(loaded.Items) != 1 {
+		t.Fatalf("expected one bookmark, got %v", loaded.Items)
+	}
+	if bm.Note != "slow checkout" || !bm.HasTag("incident") || !bm.HasTag("checkout") {
+		t.Errorf("unexpected bookmark %+v", bm)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	md := bookmarks.Markdown([]bookmarks.Bookmark{
+		{TraceID: "1-b", StartTime: start.Add(time.Minute), Note: "second"},
+		{TraceID: "1-a", StartTime: start, Method: "GET", Path: "/orders", Status: 500, ResponseTime: 1.25, Note: "a | b"},
+	})
+	if !strings.Contains(md, "| 2024-03-01 12:00:00Z | `1-a` | GET /orders | 500 | 1250ms |  | a \\| b |") {
+		t.Errorf("unexpected markdown:\n%s", md)
+	}
+	if strings.Index(md, "1-a") > strings.Index(md, "1-b") {
+		t.Errorf("expected bookmarks in time order:\n%s", md)
+	}
+}
@@ -0,0 +1,84 @@
+package bookmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Markdown writes bookmarks as an incident summary, in the order the traces
+// happened.
+func Markdown(bookmarks []Bookmark) string {
+	var b strings.Builder
+	b.WriteString("# Bookmarked traces\n\n")
+	if len(bookmarks) == 0 {
+		b.WriteString("No bookmarks.\n")
+		return b.String()
+	}
+	b.WriteString("| Time | Trace | Request | Status | Response | Tags | Note |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, bm := range sortedByStart(bookmarks) {
+		fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s | %s | %s |\n",
+			formatTime(bm.StartTime),
+			bm.TraceID,
+			markdownCell(strings.TrimSpace(bm.Method+" "+bm.Path)),
+			formatStatus(bm.Status),
+			formatResponseTime(bm.ResponseTime),
+			markdownCell(strings.Join(bm.Tags, ", ")),
+			markdownCell(bm.Note))
+	}
+	return b.String()
+}
+
+// JSON writes bookmarks as an indented JSON array, in the order the traces
+// happened.
+func JSON(bookmarks []Bookmark) (string, error) {
+	data, err := json.MarshalIndent(sortedByStart(bookmarks), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding bookmarks: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+func sortedByStart(bookmarks []Bookmark) []Bookmark {
+	sorted := append([]Bookmark{}, bookmarks...)
+	start := func(bm Bookmark) time.Time {
+		if bm.StartTime.IsZero() {
+			return bm.Added
+		}
+		return bm.StartTime
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return start(sorted[i]).Before(start(sorted[j]))
+	})
+	return sorted
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04:05Z")
+}
+
+func formatStatus(status int) string {
+	if status == 0 {
+		return ""
+	}
+	return fmt.Sprint(status)
+}
+
+func formatResponseTime(seconds float64) string {
+	if seconds == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dms", time.Duration(seconds*float64(time.Second)).Milliseconds())
+}
+
+// markdownCell keeps text from breaking out of a table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
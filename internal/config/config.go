@@ -9,13 +9,28 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"time"
 
 	"github.com/itchyny/gojq"
 )
 
 type App struct {
-	Logs         Logs     `json:"logs"`
-	ExcludePaths []string `json:"exclude_paths,omitempty"`
+	Logs         Logs      `json:"logs"`
+	TraceList    TraceList `json:"trace_list"`
+	ExcludePaths []string  `json:"exclude_paths,omitempty"`
+	// X-Ray groups managed by `tracey groups sync`
+	Groups []Group `json:"groups,omitempty"`
+	// How many traces to fetch when profiling the spans of a route
+	ProfileTraces int `json:"profile_traces,omitempty"`
+	// Patterns that look suspicious, flagged in the trace list and timeline
+	Rules []Rule `json:"rules,omitempty"`
+	// How many traces of each query the rules are run over
+	RuleTraces int `json:"rule_traces,omitempty"`
+	// Where bookmarks are kept, by default $XDG_DATA_HOME/tracey/bookmarks.json
+	BookmarksFile string `json:"bookmarks_file,omitempty"`
+	Keys          Keys   `json:"keys,omitempty"`
+	// The color palette, by default catppuccin-frappe. NO_COLOR overrides it.
+	Theme string `json:"theme,omitempty"`
 
 	// These are populated after parsing JSON
 	ParsedExcludePaths []regexp.Regexp `json:"-"`
@@ -24,11 +39,95 @@ type App struct {
 type Logs struct {
 	Groups []string   `json:"groups"`
 	Fields []LogField `json:"fields,omitempty"`
+	// A jq query extracting the severity of a log event, e.g. ".level"
+	LevelQuery string `json:"level_query,omitempty"`
+	// How to find trace IDs in log events when searching logs for traces:
+	// a jq query, e.g. ".traceId", or a regexp, where the first group is
+	// the ID if it has one. By default, anything that looks like an X-Ray
+	// trace ID.
+	TraceIDQuery   string `json:"trace_id_query,omitempty"`
+	TraceIDPattern string `json:"trace_id_pattern,omitempty"`
 
 	// These are populated after parsing JSON
-	ParsedFields []ParsedLogField `json:"-"`
+	ParsedFields         []ParsedLogField `json:"-"`
+	ParsedLevelQuery     *gojq.Query      `json:"-"`
+	ParsedTraceIDQuery   *gojq.Query      `json:"-"`
+	ParsedTraceIDPattern *regexp.Regexp   `json:"-"`
 }
 
+type TraceList struct {
+	// Columns to show, e.g. "status" or "annotation.tenant"
+	Columns []string `json:"columns,omitempty"`
+	// Column to sort by, prefixed with "-" for descending order
+	Sort string `json:"sort,omitempty"`
+	// Annotation keys to show as columns after the others, e.g. "tenant"
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// Keys picks a keymap preset and overrides its bindings.
+type Keys struct {
+	// default, vim or emacs
+	Preset string `json:"preset,omitempty"`
+	// Keys for each binding, e.g. "list.down": ["down", "ctrl+n"]. No keys
+	// unbinds it.
+	Bindings map[string][]string `json:"bindings,omitempty"`
+}
+
+type Group struct {
+	Name                 string `json:"name"`
+	FilterExpression     string `json:"filter_expression"`
+	InsightsEnabled      bool   `json:"insights_enabled,omitempty"`
+	NotificationsEnabled bool   `json:"notifications_enabled,omitempty"`
+}
+
+// Rule flags traces with spans matching either a jq query, run on each
+// segment and subsegment document, or a structured predicate.
+type Rule struct {
+	Name string `json:"name"`
+	// info, warning (the default) or error
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+	// Spans match when the query outputs true, e.g. ".aws.retries > 0"
+	Query string         `json:"query,omitempty"`
+	Span  *SpanPredicate `json:"span,omitempty"`
+	// How many spans have to match before the trace is flagged
+	MinCount int `json:"min_count,omitempty"`
+
+	// These are populated after parsing JSON
+	ParsedQuery *gojq.Query `json:"-"`
+}
+
+// SpanPredicate matches spans meeting all of its conditions.
+type SpanPredicate struct {
+	// Regexps matched against the span's name and namespace
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// A Go duration such as "1s"
+	MinDuration string `json:"min_duration,omitempty"`
+	Error       bool   `json:"error,omitempty"`
+	Fault       bool   `json:"fault,omitempty"`
+	Throttle    bool   `json:"throttle,omitempty"`
+	// Any of these HTTP response statuses
+	HTTPStatus []int `json:"http_status,omitempty"`
+	MinRetries int   `json:"min_retries,omitempty"`
+	// Regexps the span's SQL query has to match, and mustn't match
+	SQL    string `json:"sql,omitempty"`
+	NotSQL string `json:"not_sql,omitempty"`
+
+	// These are populated after parsing JSON
+	ParsedName        *regexp.Regexp `json:"-"`
+	ParsedNamespace   *regexp.Regexp `json:"-"`
+	ParsedMinDuration time.Duration  `json:"-"`
+	ParsedSQL         *regexp.Regexp `json:"-"`
+	ParsedNotSQL      *regexp.Regexp `json:"-"`
+}
+
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
 type LogField struct {
 	Title string `json:"title"`
 	Query string `json:"query"`
@@ -113,6 +212,30 @@ func Parse() (*App, error) {
 		}
 		logs.ParsedFields[i] = ParsedLogField{Title: field.Title, Query: *lf}
 	}
+	if logs.LevelQuery != "" {
+		lq, jqErr := gojq.Parse(logs.LevelQuery)
+		if jqErr != nil {
+			return nil, fmt.Errorf("error parsing log level query: %w", jqErr)
+		}
+		logs.ParsedLevelQuery = lq
+	}
+	if logs.TraceIDQuery != "" && logs.TraceIDPattern != "" {
+		return nil, errors.New("error in logs config: use one of trace_id_query and trace_id_pattern")
+	}
+	if logs.TraceIDQuery != "" {
+		tq, jqErr := gojq.Parse(logs.TraceIDQuery)
+		if jqErr != nil {
+			return nil, fmt.Errorf("error parsing trace ID query: %w", jqErr)
+		}
+		logs.ParsedTraceIDQuery = tq
+	}
+	if logs.TraceIDPattern != "" {
+		re, reErr := regexp.Compile(logs.TraceIDPattern)
+		if reErr != nil {
+			return nil, fmt.Errorf("error compiling trace ID pattern: %w", reErr)
+		}
+		logs.ParsedTraceIDPattern = re
+	}
 
 	cfg.ParsedExcludePaths = make([]regexp.Regexp, len(cfg.ExcludePaths))
 	for i, exclude := range cfg.ExcludePaths {
@@ -123,5 +246,71 @@ func Parse() (*App, error) {
 		cfg.ParsedExcludePaths[i] = *re
 	}
 
+	if cfg.ProfileTraces < 0 {
+		return nil, errors.New("error in config: profile_traces can't be negative")
+	}
+	if cfg.RuleTraces < 0 {
+		return nil, errors.New("error in config: rule_traces can't be negative")
+	}
+
+	for _, group := range cfg.Groups {
+		if group.Name == "" || group.FilterExpression == "" {
+			return nil, errors.New("error in groups config: each group needs a name and filter_expression")
+		}
+	}
+
+	for i := range cfg.Rules {
+		if err = parseRule(&cfg.Rules[i]); err != nil {
+			return nil, fmt.Errorf("error in rule %q: %w", cfg.Rules[i].Name, err)
+		}
+	}
+
 	return &cfg, nil
 }
+
+func parseRule(rule *Rule) error {
+	if rule.Name == "" || rule.Message == "" {
+		return errors.New("each rule needs a name and message")
+	}
+	if (rule.Query == "") == (rule.Span == nil) {
+		return errors.New("each rule needs either a query or a span predicate")
+	}
+	switch rule.Severity {
+	case "":
+		rule.Severity = SeverityWarning
+	case SeverityInfo, SeverityWarning, SeverityError:
+	default:
+		return fmt.Errorf("unknown severity %q", rule.Severity)
+	}
+	if rule.Query != "" {
+		q, err := gojq.Parse(rule.Query)
+		if err != nil {
+			return fmt.Errorf("error parsing query: %w", err)
+		}
+		rule.ParsedQuery = q
+	}
+	if p := rule.Span; p != nil {
+		var err error
+		compile := func(pattern string) *regexp.Regexp {
+			if pattern == "" || err != nil {
+				return nil
+			}
+			var re *regexp.Regexp
+			re, err = regexp.Compile(pattern)
+			return re
+		}
+		p.ParsedName = compile(p.Name)
+		p.ParsedNamespace = compile(p.Namespace)
+		p.ParsedSQL = compile(p.SQL)
+		p.ParsedNotSQL = compile(p.NotSQL)
+		if err != nil {
+			return fmt.Errorf("error compiling regex: %w", err)
+		}
+		if p.MinDuration != "" {
+			if p.ParsedMinDuration, err = time.ParseDuration(p.MinDuration); err != nil {
+				return fmt.Errorf("error parsing min_duration: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Package fuzzy matches what's typed against names, the way editors' command
+// palettes do.
+package fuzzy
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Score is how well pattern matches s, ignoring case. The pattern's
+// characters must appear in s in order, but not necessarily together.
+// Characters at the start of words and runs of characters score higher. ok
+// is false when s doesn't match.
+func Score(pattern, s string) (score int, ok bool) {
+	p := []rune(strings.ToLower(strings.ReplaceAll(pattern, " ", "")))
+	r := []rune(strings.ToLower(s))
+	if len(p) == 0 {
+		return 0, true
+	}
+	best, found := 0, false
+	// Try each place the first character appears, since the first may not
+	// lead to the best match
+	for start := range r {
+		if r[start] != p[0] {
+			continue
+		}
+		if score, ok := scoreFrom(p, r, start); ok && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+func scoreFrom(p, r []rune, start int) (int, bool) {
+	score, last := 0, -1
+	i := start
+	for _, c := range p {
+		for i < len(r) && r[i] != c {
+			i++
+		}
+		if i == len(r) {
+			return 0, false
+		}
+		score++
+		if i == 0 || !unicode.IsLetter(r[i-1]) && !unicode.IsDigit(r[i-1]) {
+			score += 4
+		}
+		if last >= 0 && i == last+1 {
+			score += 3
+		}
+		last = i
+		i++
+	}
+	// Prefer matches near the start
+	return score*100 - start, true
+}
+
+// Filter returns the indices of the names that match the pattern, best
+// first. Names that match equally well keep their order.
+func Filter(pattern string, names []string) []int {
+	type match struct{ index, score int }
+	matches := make([]match, 0, len(names))
+	for i, name := range names {
+		if score, ok := Score(pattern, name); ok {
+			matches = append(matches, match{index: i, score: score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return b.score - a.score
+	})
+	indices := make([]int, len(matches))
+	for i, m := range matches {
+		indices[i] = m.index
+	}
+	return indices
+}
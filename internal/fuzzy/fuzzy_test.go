@@ -0,0 +1,37 @@
+package fuzzy_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/zopu/tracey/internal/fuzzy"
+)
+
+func TestScore(t *testing.T) {
+	if _, ok := fuzzy.Score("smp", "View: Service map"); !ok {
+		t.Error("Expected the characters to match in order")
+	}
+	if _, ok := fuzzy.Score("pms", "View: Service map"); ok {
+		t.Error("Expected characters out of order not to match")
+	}
+	words, _ := fuzzy.Score("sm", "View: Service map")
+	inside, _ := fuzzy.Score("sm", "Summarize")
+	if words <= inside {
+		t.Errorf("Expected matches at the start of words to score higher, got %d and %d", words, inside)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	names := []string{"Quit", "Go to trace", "Search logs", "View: Bookmarks", "List: Bookmark"}
+	got := fuzzy.Filter("bookm", names)
+	if !slices.Equal(got, []int{3, 4}) {
+		t.Errorf("Expected equal matches to keep their order, got %v", got)
+	}
+	got = fuzzy.Filter("goto", names)
+	if !slices.Equal(got, []int{1}) {
+		t.Errorf("Expected only Go to trace to match, got %v", got)
+	}
+	if got := fuzzy.Filter("", names); len(got) != len(names) {
+		t.Errorf("Expected an empty pattern to match everything, got %v", got)
+	}
+}
@@ -0,0 +1,831 @@
+// Package keymap has the key bindings for each pane and view and the keys
+// that work everywhere, with presets and overrides from the config.
+package keymap
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zopu/tracey/internal/config"
+)
+
+type KeyMap struct {
+	Global     GlobalKeys
+	List       ListKeys
+	Details    DetailsKeys
+	ServiceMap ServiceMapKeys
+	Latency    LatencyKeys
+	Insights   InsightsKeys
+	Sampling   SamplingKeys
+	Profile    ProfileKeys
+	Bookmarks  BookmarksKeys
+	Inspector  InspectorKeys
+	Share      ShareKeys
+	Palette    PaletteKeys
+}
+
+type GlobalKeys struct {
+	Quit       key.Binding
+	Help       key.Binding
+	Command    key.Binding
+	Group      key.Binding
+	Traces     key.Binding
+	ServiceMap key.Binding
+	Latency    key.Binding
+	Insights   key.Binding
+	Sampling   key.Binding
+	Profile    key.Binding
+	Bookmarks  key.Binding
+}
+
+type ListKeys struct {
+	Up           key.Binding
+	Down         key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	Select       key.Binding
+	Sort         key.Binding
+	ReverseSort  key.Binding
+	ClearFilter  key.Binding
+	Flagged      key.Binding
+	Baseline     key.Binding
+	Compare      key.Binding
+	ProfileRoute key.Binding
+	ProfileAll   key.Binding
+	Bookmark     key.Binding
+	Share        key.Binding
+	Open         key.Binding
+	NextPane     key.Binding
+}
+
+type DetailsKeys struct {
+	ScrollUp     key.Binding
+	ScrollDown   key.Binding
+	Top          key.Binding
+	Bottom       key.Binding
+	Back         key.Binding
+	NextPane     key.Binding
+	ViewLog      key.Binding
+	ServiceGraph key.Binding
+	FlameGraph   key.Binding
+	CriticalPath key.Binding
+	SQL          key.Binding
+	Inspect      key.Binding
+	Compare      key.Binding
+	Bookmark     key.Binding
+	Share        key.Binding
+	Open         key.Binding
+	Grow         key.Binding
+	Shrink       key.Binding
+	// Keys for the logs table, log viewer and graphs
+	FilterLogs    key.Binding
+	LogLevel      key.Binding
+	CopyLog       key.Binding
+	ExportDOT     key.Binding
+	ExportMermaid key.Binding
+	ExportFolded  key.Binding
+}
+
+// InspectorKeys are for the span inspector in the details pane, which goes
+// back with the details pane's keys.
+type InspectorKeys struct {
+	Up        key.Binding
+	Down      key.Binding
+	AddFilter key.Binding
+}
+
+// ShareKeys are for the share menu. It takes every key while open, so they
+// can't clash with the global keys.
+type ShareKeys struct {
+	CopyID      key.Binding
+	CopyURL     key.Binding
+	CopyLogsURL key.Binding
+	CopyJSON    key.Binding
+	OpenConsole key.Binding
+	OpenLogs    key.Binding
+	Close       key.Binding
+}
+
+// PaletteKeys are for the command palette, where other keys are typed into
+// its search.
+type PaletteKeys struct {
+	Up    key.Binding
+	Down  key.Binding
+	Run   key.Binding
+	Close key.Binding
+}
+
+type ServiceMapKeys struct {
+	Up     key.Binding
+	Down   key.Binding
+	Top    key.Binding
+	Bottom key.Binding
+	Select key.Binding
+}
+
+type LatencyKeys struct {
+	Up          key.Binding
+	Down        key.Binding
+	NextSection key.Binding
+	GroupBy     key.Binding
+	Select      key.Binding
+	Profile     key.Binding
+}
+
+type InsightsKeys struct {
+	Up         key.Binding
+	Down       key.Binding
+	Top        key.Binding
+	Bottom     key.Binding
+	ScrollUp   key.Binding
+	ScrollDown key.Binding
+	Select     key.Binding
+	RootCause  key.Binding
+	Back       key.Binding
+}
+
+type SamplingKeys struct {
+	Up   key.Binding
+	Down key.Binding
+	Edit key.Binding
+}
+
+type ProfileKeys struct {
+	Up         key.Binding
+	Down       key.Binding
+	PageUp     key.Binding
+	PageDown   key.Binding
+	FlameGraph key.Binding
+	Export     key.Binding
+}
+
+type BookmarksKeys struct {
+	Up     key.Binding
+	Down   key.Binding
+	Select key.Binding
+	Edit   key.Binding
+	Delete key.Binding
+}
+
+func binding(desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(helpKeys(keys), desc))
+}
+
+// Default is the keymap used without a preset.
+func Default() KeyMap {
+	return KeyMap{
+		Global: GlobalKeys{
+			Quit:       binding("Quit", "q", "ctrl+c"),
+			Help:       binding("Help", "?"),
+			Command:    binding("Commands", ":", "ctrl+p"),
+			Group:      binding("Group", "ctrl+g"),
+			Traces:     binding("Traces", "1"),
+			ServiceMap: binding("Service map", "2"),
+			Latency:    binding("Latency", "3"),
+			Insights:   binding("Insights", "4"),
+			Sampling:   binding("Sampling", "5"),
+			Profile:    binding("Profile", "6"),
+			Bookmarks:  binding("Bookmarks", "7"),
+		},
+		List: ListKeys{
+			Up:           binding("Up", "up", "k"),
+			Down:         binding("Down", "down", "j"),
+			PageUp:       binding("Page up", "ctrl+u"),
+			PageDown:     binding("Page down", "ctrl+d"),
+			Select:       binding("View details", "enter", " "),
+			Sort:         binding("Sort", "s"),
+			ReverseSort:  binding("Reverse sort", "r"),
+			ClearFilter:  binding("Clear filter", "x"),
+			Flagged:      binding("Flagged", "!"),
+			Baseline:     binding("Baseline", "b"),
+			Compare:      binding("Compare", "c"),
+			ProfileRoute: binding("Profile route", "p"),
+			ProfileAll:   binding("Profile all", "P"),
+			Bookmark:     binding("Bookmark", "m"),
+			Share:        binding("Share", "y"),
+			Open:         binding("Open in console", "o"),
+			NextPane:     binding("Switch pane", "tab"),
+		},
+		Details: DetailsKeys{
+			ScrollUp:      binding("Scroll up", "pgup"),
+			ScrollDown:    binding("Scroll down", "pgdown"),
+			Top:           binding("Top", "g"),
+			Bottom:        binding("Bottom", "G"),
+			Back:          binding("Back", "esc"),
+			NextPane:      binding("Switch pane", "tab"),
+			ViewLog:       binding("View log", "enter"),
+			ServiceGraph:  binding("Service graph", "s"),
+			FlameGraph:    binding("Flame graph", "f"),
+			CriticalPath:  binding("Critical path", "p"),
+			SQL:           binding("SQL", "S"),
+			Inspect:       binding("Inspect span", "i"),
+			Compare:       binding("Compare", "c"),
+			Bookmark:      binding("Bookmark", "m"),
+			Share:         binding("Share", "y"),
+			Open:          binding("Open in console", "o"),
+			Grow:          binding("Grow timeline", "+", "="),
+			Shrink:        binding("Shrink timeline", "-", "_"),
+			FilterLogs:    binding("Filter logs", "/"),
+			LogLevel:      binding("Minimum log level", "L"),
+			CopyLog:       binding("Copy log", "y"),
+			ExportDOT:     binding("Export DOT", "d"),
+			ExportMermaid: binding("Export Mermaid", "m"),
+			ExportFolded:  binding("Export folded stacks", "e"),
+		},
+		ServiceMap: ServiceMapKeys{
+			Up:     binding("Up", "up", "k"),
+			Down:   binding("Down", "down", "j"),
+			Top:    binding("Top", "g"),
+			Bottom: binding("Bottom", "G"),
+			Select: binding("Show traces", "enter", " "),
+		},
+		Latency: LatencyKeys{
+			Up:          binding("Up", "up", "k"),
+			Down:        binding("Down", "down", "j"),
+			NextSection: binding("Switch section", "tab"),
+			GroupBy:     binding("Change grouping", "b"),
+			Select:      binding("Show matching traces", "enter", " "),
+			Profile:     binding("Profile spans", "p"),
+		},
+		Insights: InsightsKeys{
+			Up:         binding("Up", "up", "k"),
+			Down:       binding("Down", "down", "j"),
+			Top:        binding("Top", "g"),
+			Bottom:     binding("Bottom", "G"),
+			ScrollUp:   binding("Scroll up", "pgup"),
+			ScrollDown: binding("Scroll down", "pgdown"),
+			Select:     binding("Open", "enter", " "),
+			RootCause:  binding("Show root cause traces", "t"),
+			Back:       binding("Back", "esc", "backspace"),
+		},
+		Sampling: SamplingKeys{
+			Up:   binding("Scroll up", "up", "k"),
+			Down: binding("Scroll down", "down", "j"),
+			Edit: binding("Edit request", "m", "/"),
+		},
+		Profile: ProfileKeys{
+			Up:         binding("Up", "up", "k"),
+			Down:       binding("Down", "down", "j"),
+			PageUp:     binding("Page up", "pgup"),
+			PageDown:   binding("Page down", "pgdown"),
+			FlameGraph: binding("Flame graph", "f"),
+			Export:     binding("Export folded stacks", "e"),
+		},
+		Bookmarks: BookmarksKeys{
+			Up:     binding("Up", "up", "k"),
+			Down:   binding("Down", "down", "j"),
+			Select: binding("Show trace", "enter", " "),
+			Edit:   binding("Edit note and tags", "e", "m"),
+			Delete: binding("Delete", "d"),
+		},
+		Inspector: InspectorKeys{
+			Up:        binding("Up", "up", "k"),
+			Down:      binding("Down", "down", "j"),
+			AddFilter: binding("Add annotation to filter", "a", "enter"),
+		},
+		Share: ShareKeys{
+			CopyID:      binding("Copy trace ID", "i"),
+			CopyURL:     binding("Copy console URL", "u"),
+			CopyLogsURL: binding("Copy Logs Insights URL", "l"),
+			CopyJSON:    binding("Copy segment JSON", "j"),
+			OpenConsole: binding("Open console URL", "o"),
+			OpenLogs:    binding("Open Logs Insights URL", "L"),
+			Close:       binding("Close", "esc", "y", "q"),
+		},
+		Palette: PaletteKeys{
+			Up:    binding("Up", "up", "ctrl+p", "shift+tab"),
+			Down:  binding("Down", "down", "ctrl+n", "tab"),
+			Run:   binding("Run", "enter"),
+			Close: binding("Close", "esc"),
+		},
+	}
+}
+
+// Vim adds vim's paging keys to the defaults.
+func Vim() KeyMap {
+	k := Default()
+	setKeys(&k.List.PageUp, "ctrl+u", "ctrl+b")
+	setKeys(&k.List.PageDown, "ctrl+d", "ctrl+f")
+	setKeys(&k.Details.ScrollUp, "pgup", "ctrl+b")
+	setKeys(&k.Details.ScrollDown, "pgdown", "ctrl+f")
+	setKeys(&k.Insights.ScrollUp, "pgup", "ctrl+b")
+	setKeys(&k.Insights.ScrollDown, "pgdown", "ctrl+f")
+	setKeys(&k.Profile.PageUp, "pgup", "ctrl+b")
+	setKeys(&k.Profile.PageDown, "pgdown", "ctrl+f")
+	return k
+}
+
+// Emacs moves with emacs keys rather than vim's. Ctrl+G goes back, so groups
+// are picked with Alt+G, and Ctrl+P moves up, so commands are Alt+X.
+func Emacs() KeyMap {
+	k := Default()
+	setKeys(&k.Global.Command, "alt+x", ":")
+	setKeys(&k.Global.Group, "alt+g")
+	for _, up := range []*key.Binding{
+		&k.List.Up, &k.ServiceMap.Up, &k.Latency.Up, &k.Insights.Up, &k.Sampling.Up, &k.Profile.Up, &k.Bookmarks.Up,
+		&k.Inspector.Up,
+	} {
+		setKeys(up, "up", "ctrl+p")
+	}
+	for _, down := range []*key.Binding{
+		&k.List.Down, &k.ServiceMap.Down, &k.Latency.Down, &k.Insights.Down, &k.Sampling.Down, &k.Profile.Down, &k.Bookmarks.Down,
+		&k.Inspector.Down,
+	} {
+		setKeys(down, "down", "ctrl+n")
+	}
+	setKeys(&k.List.PageUp, "alt+v")
+	setKeys(&k.List.PageDown, "ctrl+v")
+	setKeys(&k.Details.ScrollUp, "pgup", "alt+v")
+	setKeys(&k.Details.ScrollDown, "pgdown", "ctrl+v")
+	setKeys(&k.Details.Top, "alt+<")
+	setKeys(&k.Details.Bottom, "alt+>")
+	setKeys(&k.Details.Back, "esc", "ctrl+g")
+	setKeys(&k.Insights.ScrollUp, "pgup", "alt+v")
+	setKeys(&k.Insights.ScrollDown, "pgdown", "ctrl+v")
+	setKeys(&k.Insights.Back, "esc", "backspace", "ctrl+g")
+	setKeys(&k.Share.Close, "esc", "y", "q", "ctrl+g")
+	setKeys(&k.Palette.Close, "esc", "ctrl+g")
+	setKeys(&k.Profile.PageUp, "pgup", "alt+v")
+	setKeys(&k.Profile.PageDown, "pgdown", "ctrl+v")
+	for _, top := range []*key.Binding{&k.ServiceMap.Top, &k.Insights.Top} {
+		setKeys(top, "alt+<")
+	}
+	for _, bottom := range []*key.Binding{&k.ServiceMap.Bottom, &k.Insights.Bottom} {
+		setKeys(bottom, "alt+>")
+	}
+	return k
+}
+
+// New builds the keymap from a preset and the bindings overriding it.
+func New(cfg config.Keys) (KeyMap, error) {
+	var k KeyMap
+	switch cfg.Preset {
+	case "", "default":
+		k = Default()
+	case "vim":
+		k = Vim()
+	case "emacs":
+		k = Emacs()
+	default:
+		return KeyMap{}, fmt.Errorf("unknown key preset %q, expected default, vim or emacs", cfg.Preset)
+	}
+	bindings := k.named()
+	for name, keys := range cfg.Bindings {
+		b, ok := bindings[name]
+		if !ok {
+			return KeyMap{}, fmt.Errorf("unknown key binding %q, expected one of %s", name, strings.Join(Names(), ", "))
+		}
+		setKeys(b, keys...)
+	}
+	if err := k.checkKeys(); err != nil {
+		return KeyMap{}, err
+	}
+	return k, nil
+}
+
+// checkKeys makes sure no pane uses a global key, since the global binding
+// would always win and the pane's would never run. Popups take every key
+// while open, so their keys only have to differ from each other.
+func (k *KeyMap) checkKeys() error {
+	global := make(map[string]string)
+	for name, b := range k.globalNamed() {
+		for _, key := range b.Keys() {
+			global[key] = name
+		}
+	}
+	panes := k.paneNamed()
+	for _, name := range slices.Sorted(maps.Keys(panes)) {
+		for _, key := range panes[name].Keys() {
+			if g, ok := global[key]; ok {
+				return fmt.Errorf("key %q of %s is already bound to %s, which works in every pane", key, name, g)
+			}
+		}
+	}
+	popups := k.popupNamed()
+	used := make(map[string]string)
+	for _, name := range slices.Sorted(maps.Keys(popups)) {
+		popup, _, _ := strings.Cut(name, ".")
+		for _, key := range popups[name].Keys() {
+			if other, ok := used[popup+" "+key]; ok {
+				return fmt.Errorf("key %q of %s is already bound to %s", key, name, other)
+			}
+			used[popup+" "+key] = name
+		}
+	}
+	return nil
+}
+
+// Names lists the bindings that can be set in the config.
+func Names() []string {
+	k := Default()
+	names := make([]string, 0)
+	for name := range k.named() {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (k *KeyMap) named() map[string]*key.Binding {
+	named := k.globalNamed()
+	maps.Copy(named, k.paneNamed())
+	maps.Copy(named, k.popupNamed())
+	return named
+}
+
+func (k *KeyMap) globalNamed() map[string]*key.Binding {
+	g := &k.Global
+	return map[string]*key.Binding{
+		"quit":             &g.Quit,
+		"help":             &g.Help,
+		"command":          &g.Command,
+		"group":            &g.Group,
+		"view.traces":      &g.Traces,
+		"view.service_map": &g.ServiceMap,
+		"view.latency":     &g.Latency,
+		"view.insights":    &g.Insights,
+		"view.sampling":    &g.Sampling,
+		"view.profile":     &g.Profile,
+		"view.bookmarks":   &g.Bookmarks,
+	}
+}
+
+func (k *KeyMap) paneNamed() map[string]*key.Binding {
+	l, d := &k.List, &k.Details
+	sm, lt, in, sa, p, b := &k.ServiceMap, &k.Latency, &k.Insights, &k.Sampling, &k.Profile, &k.Bookmarks
+	i := &k.Inspector
+	return map[string]*key.Binding{
+		"list.up":                &l.Up,
+		"list.down":              &l.Down,
+		"list.page_up":           &l.PageUp,
+		"list.page_down":         &l.PageDown,
+		"list.select":            &l.Select,
+		"list.sort":              &l.Sort,
+		"list.reverse_sort":      &l.ReverseSort,
+		"list.clear_filter":      &l.ClearFilter,
+		"list.flagged":           &l.Flagged,
+		"list.baseline":          &l.Baseline,
+		"list.compare":           &l.Compare,
+		"list.profile_route":     &l.ProfileRoute,
+		"list.profile_all":       &l.ProfileAll,
+		"list.bookmark":          &l.Bookmark,
+		"list.share":             &l.Share,
+		"list.open":              &l.Open,
+		"list.next_pane":         &l.NextPane,
+		"details.scroll_up":      &d.ScrollUp,
+		"details.scroll_down":    &d.ScrollDown,
+		"details.top":            &d.Top,
+		"details.bottom":         &d.Bottom,
+		"details.back":           &d.Back,
+		"details.next_pane":      &d.NextPane,
+		"details.view_log":       &d.ViewLog,
+		"details.service_graph":  &d.ServiceGraph,
+		"details.flame_graph":    &d.FlameGraph,
+		"details.critical_path":  &d.CriticalPath,
+		"details.sql":            &d.SQL,
+		"details.inspect":        &d.Inspect,
+		"details.compare":        &d.Compare,
+		"details.bookmark":       &d.Bookmark,
+		"details.share":          &d.Share,
+		"details.open":           &d.Open,
+		"details.grow":           &d.Grow,
+		"details.shrink":         &d.Shrink,
+		"details.filter_logs":    &d.FilterLogs,
+		"details.log_level":      &d.LogLevel,
+		"details.copy_log":       &d.CopyLog,
+		"details.export_dot":     &d.ExportDOT,
+		"details.export_mermaid": &d.ExportMermaid,
+		"details.export_folded":  &d.ExportFolded,
+		"service_map.up":         &sm.Up,
+		"service_map.down":       &sm.Down,
+		"service_map.top":        &sm.Top,
+		"service_map.bottom":     &sm.Bottom,
+		"service_map.select":     &sm.Select,
+		"latency.up":             &lt.Up,
+		"latency.down":           &lt.Down,
+		"latency.next_section":   &lt.NextSection,
+		"latency.group_by":       &lt.GroupBy,
+		"latency.select":         &lt.Select,
+		"latency.profile":        &lt.Profile,
+		"insights.up":            &in.Up,
+		"insights.down":          &in.Down,
+		"insights.top":           &in.Top,
+		"insights.bottom":        &in.Bottom,
+		"insights.scroll_up":     &in.ScrollUp,
+		"insights.scroll_down":   &in.ScrollDown,
+		"insights.select":        &in.Select,
+		"insights.root_cause":    &in.RootCause,
+		"insights.back":          &in.Back,
+		"sampling.up":            &sa.Up,
+		"sampling.down":          &sa.Down,
+		"sampling.edit":          &sa.Edit,
+		"profile.up":             &p.Up,
+		"profile.down":           &p.Down,
+		"profile.page_up":        &p.PageUp,
+		"profile.page_down":      &p.PageDown,
+		"profile.flame_graph":    &p.FlameGraph,
+		"profile.export":         &p.Export,
+		"bookmarks.up":           &b.Up,
+		"bookmarks.down":         &b.Down,
+		"bookmarks.select":       &b.Select,
+		"bookmarks.edit":         &b.Edit,
+		"bookmarks.delete":       &b.Delete,
+		"inspector.up":           &i.Up,
+		"inspector.down":         &i.Down,
+		"inspector.add_filter":   &i.AddFilter,
+	}
+}
+
+// popupNamed are the keys of popups, which take every key while open.
+func (k *KeyMap) popupNamed() map[string]*key.Binding {
+	s, p := &k.Share, &k.Palette
+	return map[string]*key.Binding{
+		"share.copy_id":       &s.CopyID,
+		"share.copy_url":      &s.CopyURL,
+		"share.copy_logs_url": &s.CopyLogsURL,
+		"share.copy_json":     &s.CopyJSON,
+		"share.open_console":  &s.OpenConsole,
+		"share.open_logs":     &s.OpenLogs,
+		"share.close":         &s.Close,
+		"palette.up":          &p.Up,
+		"palette.down":        &p.Down,
+		"palette.run":         &p.Run,
+		"palette.close":       &p.Close,
+	}
+}
+
+// setKeys rebinds a binding, keeping its description. No keys unbinds it.
+func setKeys(b *key.Binding, keys ...string) {
+	if len(keys) == 0 {
+		b.Unbind()
+		return
+	}
+	b.SetKeys(keys...)
+	b.SetHelp(helpKeys(keys), b.Help().Desc)
+}
+
+var keySymbols = map[string]string{
+	"up":     "↑",
+	"down":   "↓",
+	"left":   "←",
+	"right":  "→",
+	" ":      "space",
+	"pgup":   "PgUp",
+	"pgdown": "PgDn",
+	"enter":  "Enter",
+	"esc":    "Esc",
+	"tab":    "Tab",
+}
+
+// helpKeys describes keys for the help, e.g. "↑/k".
+func helpKeys(keys []string) string {
+	described := make([]string, len(keys))
+	for i, k := range keys {
+		if symbol, ok := keySymbols[k]; ok {
+			k = symbol
+		} else if mod, rest, found := strings.Cut(k, "+"); found && rest != "" {
+			k = strings.ToUpper(mod[:1]) + mod[1:] + "+" + strings.ToUpper(rest)
+		}
+		described[i] = k
+	}
+	return strings.Join(described, "/")
+}
+
+// keyTypes are the special keys by the names bindings use, e.g. "ctrl+d".
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for t := tea.KeyType(-200); t <= 127; t++ {
+		if name := t.String(); name != "" {
+			if _, ok := types[name]; !ok {
+				types[name] = t
+			}
+		}
+	}
+	return types
+}()
+
+// KeyMsg is the key press a binding's first key sends, so its action can be
+// run from elsewhere, e.g. the command palette. ok is false for unbound
+// bindings.
+func KeyMsg(b key.Binding) (msg tea.KeyMsg, ok bool) {
+	if !b.Enabled() || len(b.Keys()) == 0 {
+		return tea.KeyMsg{}, false
+	}
+	k := b.Keys()[0]
+	if t, ok := keyTypes[k]; ok {
+		return tea.KeyMsg{Type: t}, true
+	}
+	if rest, found := strings.CutPrefix(k, "alt+"); found && rest != "" {
+		msg, _ = KeyMsg(key.NewBinding(key.WithKeys(rest)))
+		msg.Alt = true
+		return msg, true
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}, true
+}
+
+// ShortHelp is the global keys shown in the help bar, after the pane's own.
+func (k GlobalKeys) ShortHelp() []key.Binding {
+	viewKeys := make([]string, 0)
+	for _, b := range k.Views() {
+		if b.Enabled() {
+			viewKeys = append(viewKeys, b.Help().Key)
+		}
+	}
+	switchView := key.NewBinding(key.WithKeys(), key.WithHelp(strings.Join(viewKeys, "/"), "Views"))
+	return []key.Binding{switchView, k.Command, k.Group, k.Quit}
+}
+
+func (k GlobalKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		k.Views(),
+		{k.Command, k.Group, k.Help, k.Quit},
+	}
+}
+
+// Views are the keys that switch views.
+func (k GlobalKeys) Views() []key.Binding {
+	return []key.Binding{k.Traces, k.ServiceMap, k.Latency, k.Insights, k.Sampling, k.Profile, k.Bookmarks}
+}
+
+// Actions are the other global keys that can be run from the command
+// palette.
+func (k GlobalKeys) Actions() []key.Binding {
+	return []key.Binding{k.Group, k.Help, k.Quit}
+}
+
+func (k ListKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Select, k.Sort, k.Flagged, k.Bookmark, k.Share, k.NextPane}
+}
+
+func (k ListKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.Select, k.NextPane},
+		{k.Sort, k.ReverseSort, k.Flagged, k.ClearFilter},
+		{k.Baseline, k.Compare, k.ProfileRoute, k.ProfileAll},
+		{k.Bookmark, k.Share, k.Open},
+	}
+}
+
+// Actions are the trace list's keys that can be run from the command
+// palette, leaving out moving around the list.
+func (k ListKeys) Actions() []key.Binding {
+	return []key.Binding{
+		k.Select, k.Sort, k.ReverseSort, k.Flagged, k.ClearFilter,
+		k.Baseline, k.Compare, k.ProfileRoute, k.ProfileAll, k.Bookmark, k.Share, k.Open,
+	}
+}
+
+func (k DetailsKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.ScrollUp, k.ScrollDown, k.FlameGraph, k.CriticalPath, k.SQL, k.Inspect, k.Back, k.NextPane}
+}
+
+func (k DetailsKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.ScrollUp, k.ScrollDown, k.Top, k.Bottom, k.NextPane, k.Back},
+		{k.ServiceGraph, k.FlameGraph, k.CriticalPath, k.SQL, k.Inspect, k.ViewLog},
+		{k.Compare, k.Bookmark, k.Share, k.Open, k.Grow, k.Shrink},
+		{k.FilterLogs, k.LogLevel, k.CopyLog, k.ExportDOT, k.ExportMermaid, k.ExportFolded},
+	}
+}
+
+// Actions are the details pane's keys that can be run from the command
+// palette, leaving out scrolling. Some only apply to what the pane is
+// showing, e.g. the exports to a graph.
+func (k DetailsKeys) Actions() []key.Binding {
+	return []key.Binding{
+		k.ServiceGraph, k.FlameGraph, k.CriticalPath, k.SQL, k.Inspect, k.ViewLog,
+		k.Compare, k.Bookmark, k.Share, k.Open, k.Grow, k.Shrink,
+		k.FilterLogs, k.LogLevel, k.CopyLog, k.ExportDOT, k.ExportMermaid, k.ExportFolded,
+	}
+}
+
+func (k ServiceMapKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Select}
+}
+
+func (k ServiceMapKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Up, k.Down, k.Top, k.Bottom, k.Select}}
+}
+
+// Actions are the service map's keys that can be run from the command palette, leaving out moving around.
+func (k ServiceMapKeys) Actions() []key.Binding {
+	return []key.Binding{k.Select}
+}
+
+func (k LatencyKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.NextSection, k.GroupBy, k.Select, k.Profile}
+}
+
+func (k LatencyKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.NextSection},
+		{k.GroupBy, k.Select, k.Profile},
+	}
+}
+
+// Actions are the latency view's keys that can be run from the command palette, leaving out moving around.
+func (k LatencyKeys) Actions() []key.Binding {
+	return []key.Binding{k.NextSection, k.GroupBy, k.Select, k.Profile}
+}
+
+func (k InsightsKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Select, k.RootCause, k.Back}
+}
+
+func (k InsightsKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Top, k.Bottom, k.ScrollUp, k.ScrollDown},
+		{k.Select, k.RootCause, k.Back},
+	}
+}
+
+// Actions are the insights view's keys that can be run from the command palette, leaving out moving around.
+func (k InsightsKeys) Actions() []key.Binding {
+	return []key.Binding{k.Select, k.RootCause, k.Back}
+}
+
+func (k SamplingKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Edit}
+}
+
+func (k SamplingKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Up, k.Down, k.Edit}}
+}
+
+// Actions are the sampling view's keys that can be run from the command palette, leaving out moving around.
+func (k SamplingKeys) Actions() []key.Binding {
+	return []key.Binding{k.Edit}
+}
+
+func (k ProfileKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.FlameGraph, k.Export}
+}
+
+func (k ProfileKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.FlameGraph, k.Export},
+	}
+}
+
+// Actions are the profile view's keys that can be run from the command palette, leaving out moving around.
+func (k ProfileKeys) Actions() []key.Binding {
+	return []key.Binding{k.FlameGraph, k.Export}
+}
+
+func (k BookmarksKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Select, k.Edit, k.Delete}
+}
+
+func (k BookmarksKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down},
+		{k.Select, k.Edit, k.Delete},
+	}
+}
+
+// Actions are the bookmarks view's keys that can be run from the command palette, leaving out moving around.
+func (k BookmarksKeys) Actions() []key.Binding {
+	return []key.Binding{k.Select, k.Edit, k.Delete}
+}
+
+func (k InspectorKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.AddFilter}
+}
+
+func (k InspectorKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Up, k.Down, k.AddFilter}}
+}
+
+// Actions are the span inspector's keys that can be run from the command palette, leaving out moving around.
+func (k InspectorKeys) Actions() []key.Binding {
+	return []key.Binding{k.AddFilter}
+}
+
+func (k ShareKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.CopyID, k.CopyURL, k.CopyLogsURL, k.CopyJSON, k.OpenConsole, k.OpenLogs, k.Close}
+}
+
+func (k ShareKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.CopyID, k.CopyURL, k.CopyLogsURL, k.CopyJSON},
+		{k.OpenConsole, k.OpenLogs, k.Close},
+	}
+}
+
+// Actions are the share menu's keys that can be run from the command palette.
+func (k ShareKeys) Actions() []key.Binding {
+	return []key.Binding{k.CopyID, k.CopyURL, k.CopyLogsURL, k.CopyJSON, k.OpenConsole, k.OpenLogs}
+}
+
+func (k PaletteKeys) ShortHelp() []key.Binding {
+	return []key.Binding{k.Run, k.Up, k.Down, k.Close}
+}
+
+func (k PaletteKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Up, k.Down, k.Run, k.Close}}
+}
@@ -0,0 +1,124 @@
+package keymap_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zopu/tracey/internal/config"
+	"github.com/zopu/tracey/internal/keymap"
+)
+
+func keyMsg(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestOverrides(t *testing.T) {
+	k, err := keymap.New(config.Keys{
+		Preset: "emacs",
+		Bindings: map[string][]string{
+			"list.down":      {"down", "J"},
+			"view.bookmarks": {},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Matches(keyMsg("J"), k.List.Down) || key.Matches(keyMsg("j"), k.List.Down) {
+		t.Errorf("Expected list.down to be overridden, got %v", k.List.Down.Keys())
+	}
+	if k.List.Down.Help().Key != "↓/J" || k.List.Down.Help().Desc != "Down" {
+		t.Errorf("Expected the help to follow the keys, got %+v", k.List.Down.Help())
+	}
+	if key.Matches(keyMsg("7"), k.Global.Bookmarks) {
+		t.Error("Expected an empty list of keys to unbind view.bookmarks")
+	}
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyCtrlP}, k.List.Up) {
+		t.Errorf("Expected the emacs preset to move up with ctrl+p, got %v", k.List.Up.Keys())
+	}
+}
+
+func TestUnknownBindings(t *testing.T) {
+	if _, err := keymap.New(config.Keys{Bindings: map[string][]string{"list.nope": {"n"}}}); err == nil {
+		t.Error("Expected an error for an unknown binding")
+	}
+	if _, err := keymap.New(config.Keys{Preset: "nano"}); err == nil {
+		t.Error("Expected an error for an unknown preset")
+	}
+}
+
+func TestGlobalKeyCollisions(t *testing.T) {
+	for _, preset := range []string{"default", "vim", "emacs"} {
+		if _, err := keymap.New(config.Keys{Preset: preset}); err != nil {
+			t.Errorf("Expected the %s preset to be valid, got %v", preset, err)
+		}
+	}
+	for name, keys := range map[string][]string{
+		"profile.flame_graph": {"1"},
+		"details.top":         {"ctrl+g"},
+		"group":               {"m"},
+	} {
+		if _, err := keymap.New(config.Keys{Bindings: map[string][]string{name: keys}}); err == nil {
+			t.Errorf("Expected an error for %s using %v, a global key", name, keys)
+		}
+	}
+	if _, err := keymap.New(config.Keys{Bindings: map[string][]string{"share.copy_id": {"u"}}}); err == nil {
+		t.Error("Expected an error for share.copy_id using the key of share.copy_url")
+	}
+	if _, err := keymap.New(config.Keys{Bindings: map[string][]string{"share.copy_id": {"1"}}}); err != nil {
+		t.Errorf("Expected a popup to be able to use a global key, got %v", err)
+	}
+}
+
+func TestKeyMsg(t *testing.T) {
+	for _, k := range []keymap.KeyMap{keymap.Default(), keymap.Vim(), keymap.Emacs()} {
+		bindings := append(append(append(k.Global.Views(), k.Global.Actions()...), k.List.Actions()...), k.Details.Actions()...)
+		bindings = append(bindings, k.List.PageDown, k.Details.Top, k.Details.Back)
+		bindings = slices.Concat(bindings, k.ServiceMap.Actions(), k.Latency.Actions(), k.Insights.Actions(),
+			k.Sampling.Actions(), k.Profile.Actions(), k.Bookmarks.Actions(), k.Inspector.Actions(), k.Share.Actions())
+		for _, b := range bindings {
+			msg, ok := keymap.KeyMsg(b)
+			if !ok || !key.Matches(msg, b) {
+				t.Errorf("Expected a key press matching %v, got %q", b.Keys(), msg.String())
+			}
+		}
+	}
+	b := key.NewBinding(key.WithKeys("x"))
+	b.Unbind()
+	if _, ok := keymap.KeyMsg(b); ok {
+		t.Error("Expected no key press for an unbound binding")
+	}
+}
+
+func TestPaletteCoversEveryAction(t *testing.T) {
+	// Moving around, and the keys of popups that are open, aren't commands
+	notActions := map[string]bool{
+		"command": true, "list.up": true, "list.down": true, "list.page_up": true, "list.page_down": true,
+		"list.next_pane": true, "details.scroll_up": true, "details.scroll_down": true, "details.top": true,
+		"details.bottom": true, "details.back": true, "details.next_pane": true,
+		"service_map.up": true, "service_map.down": true, "service_map.top": true, "service_map.bottom": true,
+		"latency.up": true, "latency.down": true, "insights.up": true, "insights.down": true,
+		"insights.top": true, "insights.bottom": true, "insights.scroll_up": true, "insights.scroll_down": true,
+		"sampling.up": true, "sampling.down": true, "profile.up": true, "profile.down": true,
+		"profile.page_up": true, "profile.page_down": true, "bookmarks.up": true, "bookmarks.down": true,
+		"inspector.up": true, "inspector.down": true, "share.close": true,
+		"palette.up": true, "palette.down": true, "palette.run": true, "palette.close": true,
+	}
+	for _, name := range keymap.Names() {
+		if notActions[name] {
+			continue
+		}
+		// Give the binding a key nothing else uses, then look for it
+		k, err := keymap.New(config.Keys{Bindings: map[string][]string{name: {"f20"}}})
+		if err != nil {
+			t.Fatalf("Expected %s to take f20, got %v", name, err)
+		}
+		actions := slices.Concat(k.Global.Views(), k.Global.Actions(), k.List.Actions(), k.Details.Actions(),
+			k.ServiceMap.Actions(), k.Latency.Actions(), k.Insights.Actions(), k.Sampling.Actions(),
+			k.Profile.Actions(), k.Bookmarks.Actions(), k.Inspector.Actions(), k.Share.Actions())
+		if !slices.ContainsFunc(actions, func(b key.Binding) bool { return slices.Contains(b.Keys(), "f20") }) {
+			t.Errorf("Expected %s to be in the command palette", name)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/bookmarks"
+	"github.com/zopu/tracey/internal/keymap"
+)
+
+// EditBookmarkMsg asks for the bookmark editor to be opened for a trace.
+type EditBookmarkMsg struct {
+	ID string
+}
+
+type SaveBookmarkMsg struct {
+	Bookmark bookmarks.Bookmark
+}
+
+type DeleteBookmarkMsg struct {
+	ID string
+}
+
+// SaveBookmarks writes bookmarks to their file straight away, rather than in
+// a command, so saves can't overlap or land out of order and the file is
+// written before the bookmarks change again. The command reports an error
+// without ending the session, and the bookmarks are kept so the next change
+// saves them again.
+func SaveBookmarks(b bookmarks.Bookmarks) tea.Cmd {
+	if err := b.Save(); err != nil {
+		return func() tea.Msg {
+			return StatusMsg{Msg: "couldn't save bookmarks: " + err.Error()}
+		}
+	}
+	return nil
+}
+
+// BookmarkEditor is a popup for a bookmark's note and tags.
+type BookmarkEditor struct {
+	bookmark bookmarks.Bookmark
+	inputs   []textinput.Model
+	focus    int
+	isOpen   bool
+}
+
+func NewBookmarkEditor() BookmarkEditor {
+	note := textinput.New()
+	note.Prompt = "Note: "
+	note.Placeholder = "Why this trace matters"
+	tags := textinput.New()
+	tags.Prompt = "Tags: "
+	tags.Placeholder = "incident-42, checkout"
+	inputs := []textinput.Model{note, tags}
+	for i := range inputs {
+		// Blink messages aren't routed to the editor
+		inputs[i].Cursor.SetMode(cursor.CursorStatic)
+	}
+	return BookmarkEditor{inputs: inputs}
+}
+
+func (e *BookmarkEditor) Open(bm bookmarks.Bookmark) {
+	e.bookmark = bm
+	e.inputs[0].SetValue(bm.Note)
+	e.inputs[1].SetValue(strings.Join(bm.Tags, ", "))
+	e.setFocus(0)
+	e.isOpen = true
+}
+
+func (e BookmarkEditor) IsOpen() bool {
+	return e.isOpen
+}
+
+func (e *BookmarkEditor) SetFocus(bool) {}
+
+// IsCapturingInput is true while open, so no other keys get through.
+func (e *BookmarkEditor) IsCapturingInput() bool {
+	return e.isOpen
+}
+
+func (e *BookmarkEditor) SetSize(width, _ int) {
+	for i := range e.inputs {
+		e.inputs[i].Width = max(width-10, 0)
+	}
+}
+
+func (e *BookmarkEditor) setFocus(i int) {
+	e.focus = i
+	for j := range e.inputs {
+		if j == i {
+			e.inputs[j].Focus()
+		} else {
+			e.inputs[j].Blur()
+		}
+	}
+}
+
+func (e *BookmarkEditor) Update(msg tea.Msg) tea.Cmd {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return nil
+	}
+	switch keyMsg.String() {
+	case "esc":
+		e.isOpen = false
+		return nil
+	case "tab", "down", "shift+tab", "up":
+		e.setFocus((e.focus + 1) % len(e.inputs))
+		return nil
+	case "enter":
+		e.isOpen = false
+		bm := e.bookmark
+		bm.Note = strings.TrimSpace(e.inputs[0].Value())
+		bm.Tags = bookmarks.ParseTags(e.inputs[1].Value())
+		return func() tea.Msg {
+			return SaveBookmarkMsg{Bookmark: bm}
+		}
+	}
+	var cmd tea.Cmd
+	e.inputs[e.focus], cmd = e.inputs[e.focus].Update(msg)
+	return cmd
+}
+
+func (e BookmarkEditor) View() string {
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Bookmark "+e.bookmark.TraceID) + "\n")
+	if request := strings.TrimSpace(e.bookmark.Method + " " + e.bookmark.Path); request != "" {
+		b.WriteString(mutedStyle.Render(request) + "\n")
+	}
+	b.WriteString("\n")
+	for _, input := range e.inputs {
+		b.WriteString(input.View() + "\n")
+	}
+	b.WriteString("\n" + mutedStyle.Render("Enter: Save | Tab: Next field | Esc: Cancel"))
+	return b.String()
+}
+
+// BookmarksView lists the bookmarked traces, newest first.
+type BookmarksView struct {
+	Keys      keymap.BookmarksKeys
+	bookmarks []bookmarks.Bookmark
+	cursor    int
+	viewport  viewport.Model
+}
+
+func NewBookmarksView() BookmarksView {
+	return BookmarksView{Keys: keymap.Default().Bookmarks, viewport: viewport.New(0, 0)}
+}
+
+func (v *BookmarksView) SetBookmarks(items []bookmarks.Bookmark) {
+	v.bookmarks = make([]bookmarks.Bookmark, len(items))
+	for i, bm := range items {
+		v.bookmarks[len(items)-1-i] = bm
+	}
+	v.cursor = min(v.cursor, max(len(v.bookmarks)-1, 0))
+	v.refreshViewport()
+}
+
+func (v *BookmarksView) SetFocus(bool) {}
+
+func (v *BookmarksView) IsCapturingInput() bool {
+	return false
+}
+
+func (v *BookmarksView) SetSize(width, height int) {
+	v.viewport.Width = width
+	v.viewport.Height = height
+	v.refreshViewport()
+}
+
+func (v *BookmarksView) Update(msg tea.Msg) tea.Cmd {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || len(v.bookmarks) == 0 {
+		return nil
+	}
+	var cmd tea.Cmd
+	id := v.bookmarks[v.cursor].TraceID
+	switch {
+	case key.Matches(keyMsg, v.Keys.Up):
+		v.cursor = max(v.cursor-1, 0)
+	case key.Matches(keyMsg, v.Keys.Down):
+		v.cursor = min(v.cursor+1, len(v.bookmarks)-1)
+	case key.Matches(keyMsg, v.Keys.Select):
+		cmd = func() tea.Msg {
+			return GotoTraceMsg{ID: aws.TraceID(id)}
+		}
+	case key.Matches(keyMsg, v.Keys.Edit):
+		cmd = func() tea.Msg {
+			return EditBookmarkMsg{ID: id}
+		}
+	case key.Matches(keyMsg, v.Keys.Delete):
+		cmd = func() tea.Msg {
+			return DeleteBookmarkMsg{ID: id}
+		}
+	}
+	v.refreshViewport()
+	return cmd
+}
+
+func (v *BookmarksView) refreshViewport() {
+	if len(v.bookmarks) == 0 {
+		v.viewport.SetContent("No bookmarks yet. Press m on a trace to bookmark it.")
+		return
+	}
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	selectedStyle := theme.Selected(lipgloss.NewStyle())
+	tagStyle := lipgloss.NewStyle().Foreground(theme.Accent)
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(fmt.Sprintf("%d bookmarked traces", len(v.bookmarks))) + "\n\n")
+	cursorLine := 0
+	for i, bm := range v.bookmarks {
+		start := ""
+		if !bm.StartTime.IsZero() {
+			start = bm.StartTime.Local().Format("01-02 15:04:05")
+		}
+		status := ""
+		if bm.Status != 0 {
+			status = fmt.Sprint(bm.Status)
+		}
+		line := fmt.Sprintf("%-14s  %-35s  %3s  %s", start, bm.TraceID, status, strings.TrimSpace(bm.Method+" "+bm.Path))
+		b.WriteString(renderCursorLine(line, i == v.cursor, selectedStyle))
+		if i == v.cursor {
+			cursorLine = strings.Count(b.String(), "\n")
+		}
+		details := make([]string, 0, 2)
+		if len(bm.Tags) > 0 {
+			details = append(details, tagStyle.Render("#"+strings.Join(bm.Tags, " #")))
+		}
+		if bm.Note != "" {
+			details = append(details, bm.Note)
+		}
+		if len(details) > 0 {
+			b.WriteString("    " + strings.Join(details, "  ") + "\n")
+		}
+	}
+	b.WriteString("\n" + mutedStyle.Render(footerHelp(v.Keys.Select, v.Keys.Edit, v.Keys.Delete)))
+
+	v.viewport.SetContent(b.String())
+	if cursorLine <= v.viewport.YOffset {
+		v.viewport.SetYOffset(cursorLine - 1)
+	} else if cursorLine > v.viewport.YOffset+v.viewport.Height {
+		v.viewport.SetYOffset(cursorLine - v.viewport.Height)
+	}
+}
+
+func (v BookmarksView) View() string {
+	return v.viewport.View()
+}
@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evertras/bubble-table/table"
+	"github.com/samber/lo"
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/aws"
+)
+
+// CompareTracesMsg asks for a trace to be compared with a baseline trace.
+type CompareTracesMsg struct {
+	Baseline aws.TraceID
+	Other    aws.TraceID
+}
+
+type TraceComparisonMsg struct {
+	Baseline *aws.TraceDetails
+	Other    *aws.TraceDetails
+}
+
+// BaselineFailedMsg reports a baseline that couldn't be fetched, e.g. one
+// that has expired or is in another region, so it can be cleared.
+type BaselineFailedMsg struct {
+	Baseline aws.TraceID
+	Status   StatusMsg
+}
+
+// FetchTraceComparison gets both traces to compare. Failures are reported
+// without ending the session.
+func FetchTraceComparison(baseline, other aws.TraceID) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		b, err := aws.FetchTraceDetails(ctx, baseline)
+		if err != nil {
+			return BaselineFailedMsg{
+				Baseline: baseline,
+				Status:   StatusMsg{Msg: fmt.Sprintf("cleared baseline %s: %s", baseline, err)},
+			}
+		}
+		o, err := aws.FetchTraceDetails(ctx, other)
+		if err != nil {
+			return StatusMsg{Msg: err.Error()}
+		}
+		return TraceComparisonMsg{Baseline: b, Other: o}
+	}
+}
+
+// Differences smaller than this, or than this share of the baseline, aren't
+// highlighted.
+const (
+	minHighlightedDelta      = time.Millisecond
+	minHighlightedDeltaShare = 0.1
+)
+
+// newComparisonTimeline shows a trace's spans alongside the same spans in a
+// baseline trace. Slower spans are red, faster ones green and spans only in
+// one of the traces yellow.
+func newComparisonTimeline(baseline, other aws.TraceDetails, width int) timeline {
+	diffs := analysis.CompareTraces(baseline, other)
+	rows := lo.Map(diffs, func(d analysis.SpanDiff, _ int) table.Row {
+		return comparisonRow(d)
+	})
+	t := table.New(comparisonColumns(width)).
+		WithRows(rows).
+		WithKeyMap(scrollableTableKeyMap()).
+		WithMultiline(true).
+		WithBaseStyle(
+			lipgloss.NewStyle().
+				Foreground(theme.Text).
+				BorderForeground(theme.Muted).
+				Bold(false)).
+		HeaderStyle(
+			lipgloss.NewStyle().
+				Bold(true))
+	return timeline{tableModel: t, columns: comparisonColumns}
+}
+
+func comparisonColumns(width int) []table.Column {
+	return []table.Column{
+		table.NewColumn("Start Time", "Start Time", 12),
+		table.NewColumn("Duration", "Duration", 12),
+		table.NewColumn("Baseline", "Baseline", 12),
+		table.NewColumn("Delta", "Δ", 18),
+		table.NewColumn("Details", "Details", max(width-64, 10)),
+	}
+}
+
+func comparisonRow(d analysis.SpanDiff) table.Row {
+	details := []string{strings.Repeat("  ", d.Depth) + d.Name}
+	data := table.RowData{"Start Time": "", "Duration": "—", "Baseline": "—", "Delta": ""}
+	style := lipgloss.NewStyle()
+
+	b, inBaseline := d.Baseline.Get()
+	o, inOther := d.Other.Get()
+	if inBaseline {
+		data["Baseline"] = formatMillis(b.Duration)
+		data["Start Time"] = formatMillis(b.Offset)
+	}
+	if inOther {
+		data["Duration"] = formatMillis(o.Duration)
+		data["Start Time"] = formatMillis(o.Offset)
+	}
+	switch {
+	case !inOther:
+		data["Delta"] = "only in baseline"
+		style = style.Foreground(theme.Warning)
+	case !inBaseline:
+		data["Delta"] = "only in this trace"
+		style = style.Foreground(theme.Warning)
+	default:
+		delta := d.Delta().MustGet()
+		data["Delta"] = formatDelta(delta, b.Duration)
+		if significantDelta(delta, b.Duration) {
+			if delta > 0 {
+				style = style.Foreground(theme.Error)
+			} else {
+				style = style.Foreground(theme.Success)
+			}
+		}
+	}
+	for _, c := range d.Changes {
+		details = append(details, strings.Repeat("  ", d.Depth+1)+"≠ "+c)
+	}
+	if len(d.Changes) > 0 {
+		style = style.Bold(true)
+	}
+	data["Details"] = strings.Join(details, "\n")
+	return table.NewRow(data).WithStyle(style)
+}
+
+func significantDelta(delta, baseline time.Duration) bool {
+	abs := delta.Abs()
+	return abs >= minHighlightedDelta && float64(abs) >= minHighlightedDeltaShare*float64(baseline)
+}
+
+func formatDelta(delta, baseline time.Duration) string {
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+	}
+	s := sign + formatMillis(delta.Abs())
+	if baseline > 0 {
+		s += fmt.Sprintf(" (%s%.0f%%)", sign, float64(delta.Abs())/float64(baseline)*100)
+	}
+	return s
+}
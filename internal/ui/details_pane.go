@@ -2,13 +2,21 @@ package ui
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/evertras/bubble-table/table"
+	"github.com/itchyny/gojq"
 	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/analysis"
 	"github.com/zopu/tracey/internal/aws"
 	"github.com/zopu/tracey/internal/config"
+	"github.com/zopu/tracey/internal/keymap"
 )
 
 type TraceDetailsMsg struct {
@@ -42,13 +50,90 @@ const (
 	detailSelectedLogs
 )
 
+const (
+	// Lines taken by a table section that aren't rows: the section title,
+	// borders, header, footer and trailing blank line.
+	tableSectionChrome = 8
+
+	defaultTimelineShare = 50
+	minTimelineShare     = 20
+	maxTimelineShare     = 80
+	timelineShareStep    = 10
+)
+
 type DetailsPane struct {
 	LogFields     []config.ParsedLogField
-	Logs          mo.Option[aws.LogData]
+	Keys          keymap.DetailsKeys
+	InspectorKeys keymap.InspectorKeys
+	LogLevelQuery *gojq.Query
+	Rules         []config.Rule
 	focused       bool
-	Width         int
+	width, height int
+	viewport      viewport.Model
+	trace         mo.Option[aws.TraceDetails]
 	timeline      mo.Option[timeline]
+	logs          mo.Option[logsTable]
+	traceGraph    mo.Option[analysis.ServiceGraph]
+	flameGraph    mo.Option[[]analysis.FlameNode]
+	sqlSummary    mo.Option[analysis.SQLSummary]
+	inspector     mo.Option[spanInspector]
+	// Set when the trace is being compared with a baseline trace
+	baseline  mo.Option[aws.TraceDetails]
+	comparing bool
+	// Set while the critical path is highlighted in the timeline
+	criticalPath  bool
+	status        string
+	logViewer     mo.Option[logViewer]
 	selectedTable int
+	// Percentage of the pane height given to the timeline when logs are shown
+	timelineShare int
+}
+
+func NewDetailsPane(logsConfig config.Logs, rules []config.Rule) DetailsPane {
+	return DetailsPane{
+		LogFields:     logsConfig.ParsedFields,
+		Keys:          keymap.Default().Details,
+		InspectorKeys: keymap.Default().Inspector,
+		LogLevelQuery: logsConfig.ParsedLevelQuery,
+		Rules:         rules,
+		viewport:      viewport.New(0, 0),
+		timelineShare: defaultTimelineShare,
+	}
+}
+
+// scrollableTableKeyMap is the table key map used inside the details pane.
+// PgUp/PgDn/g/G are left to the pane's viewport, so tables page with h/l.
+func scrollableTableKeyMap() table.KeyMap {
+	keys := table.DefaultKeyMap()
+	keys.PageDown = key.NewBinding(key.WithKeys("right", "l"))
+	keys.PageUp = key.NewBinding(key.WithKeys("left", "h"))
+	keys.PageFirst = key.NewBinding(key.WithKeys("home"))
+	keys.PageLast = key.NewBinding(key.WithKeys("end"))
+	return keys
+}
+
+func (d *DetailsPane) SetSize(width, height int) {
+	d.width = width
+	d.height = height
+	d.viewport.Width = width
+	d.viewport.Height = height
+	d.layout()
+}
+
+// layout divides the pane height between the timeline and logs tables.
+func (d *DetailsPane) layout() {
+	timelineHeight := d.height
+	if d.logs.IsPresent() {
+		timelineHeight = d.height * d.timelineShare / 100
+	}
+	logsHeight := d.height - timelineHeight
+	d.timeline = d.timeline.Map(func(t timeline) (timeline, bool) {
+		return t.WithSize(d.width, max(timelineHeight-tableSectionChrome, 1)), true
+	})
+	d.logs = d.logs.Map(func(l logsTable) (logsTable, bool) {
+		return l.WithSize(d.width, max(logsHeight-tableSectionChrome, 1)), true
+	})
+	d.refreshViewport()
 }
 
 func (d *DetailsPane) SetFocus(focus bool) {
@@ -59,32 +144,146 @@ func (d *DetailsPane) SetFocus(focus bool) {
 		return
 	}
 	d.selectedTable = detailSelectedNone
+	d.SetTimelineFocus(false)
+	d.SetLogsFocus(false)
+	d.refreshViewport()
 }
 
 func (d *DetailsPane) Update(msg tea.Msg) tea.Cmd {
+	cmd := d.update(msg)
+	d.refreshViewport()
+	return cmd
+}
+
+//nolint:gocognit,gocyclo // Dispatches to whichever element is selected
+func (d *DetailsPane) update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case TraceDetailsMsg:
-		d.timeline = mo.Some(newTimeline(*msg.Trace, d.Width))
-		d.Logs = mo.None[aws.LogData]()
+		d.trace = mo.Some(*msg.Trace)
+		d.timeline = mo.Some(d.newTimeline(*msg.Trace))
+		d.logs = mo.None[logsTable]()
+		d.logViewer = mo.None[logViewer]()
+		d.traceGraph = mo.None[analysis.ServiceGraph]()
+		d.flameGraph = mo.None[[]analysis.FlameNode]()
+		d.sqlSummary = mo.None[analysis.SQLSummary]()
+		d.inspector = mo.None[spanInspector]()
+		d.baseline = mo.None[aws.TraceDetails]()
+		d.comparing = false
+		d.status = ""
+		d.viewport.GotoTop()
+		d.layout()
 		if msg.LogsQueryID != nil {
 			return FetchLogs(*msg.LogsQueryID, time.Second)
 		}
+	case TraceComparisonMsg:
+		d.trace = mo.Some(*msg.Other)
+		d.baseline = mo.Some(*msg.Baseline)
+		d.comparing = true
+		d.timeline = mo.Some(newComparisonTimeline(*msg.Baseline, *msg.Other, d.width))
+		d.logs = mo.None[logsTable]()
+		d.logViewer = mo.None[logViewer]()
+		d.traceGraph = mo.None[analysis.ServiceGraph]()
+		d.flameGraph = mo.None[[]analysis.FlameNode]()
+		d.sqlSummary = mo.None[analysis.SQLSummary]()
+		d.inspector = mo.None[spanInspector]()
+		d.status = ""
+		d.SetTimelineFocus(d.selectedTable == detailSelectedTimeline)
+		d.viewport.GotoTop()
+		d.layout()
 	case ClearTraceDetailsMsg:
+		d.baseline = mo.None[aws.TraceDetails]()
+		d.comparing = false
+		d.trace = mo.None[aws.TraceDetails]()
 		d.timeline = mo.None[timeline]()
-		d.Logs = mo.None[aws.LogData]()
+		d.logs = mo.None[logsTable]()
+		d.logViewer = mo.None[logViewer]()
+		d.traceGraph = mo.None[analysis.ServiceGraph]()
+		d.flameGraph = mo.None[[]analysis.FlameNode]()
+		d.sqlSummary = mo.None[analysis.SQLSummary]()
+		d.inspector = mo.None[spanInspector]()
+	case TraceLogsMsg:
+		if msg.Logs.IsEmpty() || len(d.LogFields) == 0 {
+			d.logs = mo.None[logsTable]()
+			return nil
+		}
+		d.logs = mo.Some(newLogsTable(*msg.Logs, d.LogFields, d.LogLevelQuery, d.width, d.Keys))
+		d.SetLogsFocus(d.selectedTable == detailSelectedLogs)
+		d.layout()
+	case ExportedMsg:
+		d.status = msg.Status()
+	case LogCopiedMsg:
+		if v, ok := d.logViewer.Get(); ok {
+			v, cmd := v.Update(msg)
+			d.logViewer = mo.Some(v)
+			return cmd
+		}
 	case tea.KeyMsg:
-		switch msg.String() { //nolint:gocritic // standard pattern
-		case "tab":
+		if d.IsCapturingInput() {
+			l, cmd := d.logs.MustGet().Update(msg)
+			d.logs = mo.Some(l)
+			return cmd
+		}
+		keys := d.Keys
+		switch {
+		case key.Matches(msg, keys.ScrollDown):
+			d.viewport.ViewDown()
+			return nil
+		case key.Matches(msg, keys.ScrollUp):
+			d.viewport.ViewUp()
+			return nil
+		case key.Matches(msg, keys.Top):
+			d.viewport.GotoTop()
+			return nil
+		case key.Matches(msg, keys.Bottom):
+			d.viewport.GotoBottom()
+			return nil
+		}
+		if g, ok := d.traceGraph.Get(); ok {
+			return d.updateTraceGraph(msg, g)
+		}
+		if f, ok := d.flameGraph.Get(); ok {
+			return d.updateFlameGraph(msg, f)
+		}
+		if i, ok := d.inspector.Get(); ok {
+			if key.Matches(msg, keys.Back, keys.Inspect) {
+				d.inspector = mo.None[spanInspector]()
+				d.viewport.GotoTop()
+				return nil
+			}
+			i, cmd := i.Update(msg)
+			d.inspector = mo.Some(i)
+			return cmd
+		}
+		if d.sqlSummary.IsPresent() {
+			if key.Matches(msg, keys.Back, keys.SQL) {
+				d.sqlSummary = mo.None[analysis.SQLSummary]()
+				d.viewport.GotoTop()
+			}
+			return nil
+		}
+		if v, ok := d.logViewer.Get(); ok {
+			if key.Matches(msg, keys.Back, keys.ViewLog) {
+				d.logViewer = mo.None[logViewer]()
+				d.viewport.GotoTop()
+				return nil
+			}
+			v, cmd := v.Update(msg)
+			d.logViewer = mo.Some(v)
+			return cmd
+		}
+		switch {
+		case key.Matches(msg, keys.NextPane):
 			switch d.selectedTable {
 			case detailSelectedNone:
 				d.selectedTable = detailSelectedTimeline
 				d.SetTimelineFocus(true)
 			case detailSelectedTimeline:
 				d.SetTimelineFocus(false)
-				if d.Logs.IsPresent() &&
-					d.Logs.MustGet().Results != nil &&
-					len(d.Logs.MustGet().Results.Results) > 0 {
+				if d.logs.IsPresent() {
 					d.selectedTable = detailSelectedLogs
+					d.SetLogsFocus(true)
+					d.refreshViewport()
+					d.viewport.SetYOffset(lipgloss.Height(d.timelineSection()))
 					return nil
 				}
 				return func() tea.Msg {
@@ -93,10 +292,77 @@ func (d *DetailsPane) Update(msg tea.Msg) tea.Cmd {
 			case detailSelectedLogs:
 				d.selectedTable = detailSelectedNone
 				d.SetTimelineFocus(false)
+				d.SetLogsFocus(false)
 				return func() tea.Msg {
 					return SelectNextPaneMsg{}
 				}
 			}
+		case key.Matches(msg, keys.ViewLog):
+			if d.selectedTable == detailSelectedLogs && d.logs.IsPresent() {
+				if message, ok := d.logs.MustGet().HighlightedMessage(); ok {
+					d.logViewer = mo.Some(newLogViewer(message, d.Keys))
+					d.viewport.GotoTop()
+				}
+				return nil
+			}
+		case key.Matches(msg, keys.ServiceGraph):
+			if td, ok := d.trace.Get(); ok {
+				d.traceGraph = mo.Some(analysis.BuildServiceGraph(td))
+				d.status = ""
+				d.viewport.GotoTop()
+			}
+			return nil
+		case key.Matches(msg, keys.FlameGraph):
+			if td, ok := d.trace.Get(); ok {
+				d.flameGraph = mo.Some(analysis.FlameGraph(td))
+				d.status = ""
+				d.viewport.GotoTop()
+			}
+			return nil
+		case key.Matches(msg, keys.Inspect):
+			d.inspectHighlightedSpan()
+			return nil
+		case key.Matches(msg, keys.Bookmark):
+			if td, ok := d.trace.Get(); ok {
+				return func() tea.Msg {
+					return EditBookmarkMsg{ID: string(td.ID)}
+				}
+			}
+			return nil
+		case key.Matches(msg, keys.Share):
+			if td, ok := d.trace.Get(); ok {
+				return func() tea.Msg {
+					return ShareTraceMsg{ID: td.ID}
+				}
+			}
+			return nil
+		case key.Matches(msg, keys.Open):
+			if td, ok := d.trace.Get(); ok {
+				return func() tea.Msg {
+					return OpenTraceMsg{ID: td.ID}
+				}
+			}
+			return nil
+		case key.Matches(msg, keys.SQL):
+			if td, ok := d.trace.Get(); ok {
+				d.sqlSummary = mo.Some(analysis.SummarizeSQL(td))
+				d.viewport.GotoTop()
+			}
+			return nil
+		case key.Matches(msg, keys.Compare):
+			d.toggleComparison()
+			return nil
+		case key.Matches(msg, keys.CriticalPath):
+			d.toggleCriticalPath()
+			return nil
+		case key.Matches(msg, keys.Grow):
+			d.timelineShare = min(d.timelineShare+timelineShareStep, maxTimelineShare)
+			d.layout()
+			return nil
+		case key.Matches(msg, keys.Shrink):
+			d.timelineShare = max(d.timelineShare-timelineShareStep, minTimelineShare)
+			d.layout()
+			return nil
 		}
 	}
 	if d.selectedTable == detailSelectedTimeline && d.timeline.IsPresent() {
@@ -104,42 +370,230 @@ func (d *DetailsPane) Update(msg tea.Msg) tea.Cmd {
 		d.timeline = mo.Some(t)
 		return cmd
 	}
+	if d.selectedTable == detailSelectedLogs && d.logs.IsPresent() {
+		l, cmd := d.logs.MustGet().Update(msg)
+		d.logs = mo.Some(l)
+		return cmd
+	}
 	return nil
 }
 
+// IsCapturingInput is true while a text input in the pane has focus.
+func (d DetailsPane) IsCapturingInput() bool {
+	return d.selectedTable == detailSelectedLogs &&
+		d.logs.IsPresent() &&
+		d.logs.MustGet().IsFiltering()
+}
+
+// Actions are the keys that can be run from the command palette for what
+// the pane is showing, since the same key can do different things in, e.g.,
+// a graph and the timeline.
+func (d DetailsPane) Actions() []key.Binding {
+	keys := d.Keys
+	switch {
+	case d.traceGraph.IsPresent():
+		return []key.Binding{keys.ExportDOT, keys.ExportMermaid}
+	case d.flameGraph.IsPresent():
+		return []key.Binding{keys.ExportFolded}
+	case d.inspector.IsPresent():
+		return d.InspectorKeys.Actions()
+	case d.sqlSummary.IsPresent():
+		return nil
+	case d.logViewer.IsPresent():
+		return []key.Binding{keys.CopyLog}
+	}
+	actions := []key.Binding{
+		keys.ServiceGraph, keys.FlameGraph, keys.CriticalPath, keys.SQL, keys.Inspect,
+		keys.Compare, keys.Bookmark, keys.Share, keys.Open, keys.Grow, keys.Shrink,
+	}
+	if d.selectedTable == detailSelectedLogs && d.logs.IsPresent() {
+		actions = append(actions, keys.ViewLog, keys.FilterLogs, keys.LogLevel)
+	}
+	return actions
+}
+
+// TraceID is the trace being shown, once its details have loaded.
+func (d DetailsPane) TraceID() mo.Option[aws.TraceID] {
+	td, ok := d.trace.Get()
+	if !ok {
+		return mo.None[aws.TraceID]()
+	}
+	return mo.Some(td.ID)
+}
+
+// IsInspecting is true while a span is open in the span inspector.
+func (d DetailsPane) IsInspecting() bool {
+	return d.inspector.IsPresent()
+}
+
+func (d *DetailsPane) updateTraceGraph(msg tea.KeyMsg, g analysis.ServiceGraph) tea.Cmd {
+	id := string(d.trace.MustGet().ID)
+	switch {
+	case key.Matches(msg, d.Keys.Back, d.Keys.ServiceGraph):
+		d.traceGraph = mo.None[analysis.ServiceGraph]()
+		d.viewport.GotoTop()
+	case key.Matches(msg, d.Keys.ExportDOT):
+		return exportFile("trace-"+id+".dot", g.DOT())
+	case key.Matches(msg, d.Keys.ExportMermaid):
+		return exportFile("trace-"+id+".mmd", g.Mermaid())
+	}
+	return nil
+}
+
+func (d *DetailsPane) updateFlameGraph(msg tea.KeyMsg, f []analysis.FlameNode) tea.Cmd {
+	id := string(d.trace.MustGet().ID)
+	switch {
+	case key.Matches(msg, d.Keys.Back, d.Keys.FlameGraph):
+		d.flameGraph = mo.None[[]analysis.FlameNode]()
+		d.viewport.GotoTop()
+	case key.Matches(msg, d.Keys.ExportFolded):
+		return exportFile("trace-"+id+".folded", analysis.FoldedStacks(f))
+	}
+	return nil
+}
+
+// toggleComparison switches the timeline between the comparison with the
+// baseline and the trace on its own.
+func (d *DetailsPane) toggleComparison() {
+	baseline, ok := d.baseline.Get()
+	td, hasTrace := d.trace.Get()
+	if !ok || !hasTrace {
+		return
+	}
+	d.comparing = !d.comparing
+	if d.comparing {
+		d.timeline = mo.Some(newComparisonTimeline(baseline, td, d.width))
+	} else {
+		d.timeline = mo.Some(d.newTimeline(td))
+	}
+	d.SetTimelineFocus(d.selectedTable == detailSelectedTimeline)
+	d.layout()
+}
+
+// inspectHighlightedSpan opens the span in the highlighted timeline row.
+func (d *DetailsPane) inspectHighlightedSpan() {
+	td, ok := d.trace.Get()
+	if !ok || d.selectedTable != detailSelectedTimeline || !d.timeline.IsPresent() {
+		return
+	}
+	analysis.FindSpan(td, d.timeline.MustGet().HighlightedSpanID()).ForEach(func(span analysis.SpanNode) {
+		d.inspector = mo.Some(newSpanInspector(span, d.InspectorKeys, joinHelp("Back", d.Keys.Back, d.Keys.Inspect)))
+		d.viewport.GotoTop()
+	})
+}
+
+// toggleCriticalPath shows or hides the critical path in the timeline. It
+// stays on for the next traces selected.
+func (d *DetailsPane) toggleCriticalPath() {
+	td, ok := d.trace.Get()
+	if !ok || d.comparing {
+		return
+	}
+	d.criticalPath = !d.criticalPath
+	d.timeline = mo.Some(d.newTimeline(td))
+	d.SetTimelineFocus(d.selectedTable == detailSelectedTimeline)
+	d.layout()
+}
+
+func (d DetailsPane) newTimeline(td aws.TraceDetails) timeline {
+	var path []analysis.CriticalSpan
+	if d.criticalPath {
+		path = analysis.CriticalPath(td)
+	}
+	return newTimeline(td, d.width, path, analysis.CheckRules(td, d.Rules))
+}
+
 func (d *DetailsPane) SetTimelineFocus(focus bool) {
 	d.timeline = d.timeline.Map(func(t timeline) (timeline, bool) {
 		return t.SetFocus(focus), true
 	})
 }
 
+func (d *DetailsPane) SetLogsFocus(focus bool) {
+	d.logs = d.logs.Map(func(l logsTable) (logsTable, bool) {
+		return l.SetFocus(focus), true
+	})
+}
+
 type timeLineRow struct {
+	id        string
 	startTime time.Duration
 	duration  time.Duration
 	details   []string
 }
 
-func (d DetailsPane) View() string {
+func (d DetailsPane) timelineSection() string {
+	title := "Timeline (" + footerHelp(withHelp(d.Keys.CriticalPath, "Show critical path")) + "):"
+	if d.criticalPath {
+		title = "Timeline with the critical path (" + footerHelp(withHelp(d.Keys.CriticalPath, "Hide it")) + "):"
+	}
+	if baseline, ok := d.baseline.Get(); ok {
+		if d.comparing {
+			title = fmt.Sprintf("Compared with baseline %s (%s):", baseline.ID,
+				footerHelp(withHelp(d.Keys.Compare, "Show this trace only")))
+		} else {
+			title = fmt.Sprintf("Timeline (%s):",
+				footerHelp(withHelp(d.Keys.Compare, "Compare with baseline "+string(baseline.ID))))
+		}
+	}
+	s := title + "\n"
+	if d.criticalPath && !d.comparing {
+		s += renderCriticalPath(analysis.CriticalPath(d.trace.MustGet()))
+	}
+	return s + d.timeline.MustGet().View() + "\n"
+}
+
+func (d DetailsPane) content() string {
 	if !d.timeline.IsPresent() {
-		s := "Select a trace to view"
-		return s
+		return "Select a trace to view"
 	}
 
-	s := "Timeline:\n"
-	s += d.timeline.MustGet().View()
-	s += "\n"
+	if g, ok := d.traceGraph.Get(); ok {
+		footer := footerHelp(joinHelp("Back", d.Keys.Back, d.Keys.ServiceGraph), d.Keys.ExportDOT, d.Keys.ExportMermaid)
+		if d.status != "" {
+			footer += " | " + d.status
+		}
+		return "Service graph:\n" + renderTraceGraph(g) + "\n" +
+			lipgloss.NewStyle().Foreground(theme.Muted).Render(footer) + "\n"
+	}
 
-	d.Logs.ForEach(func(logs aws.LogData) {
-		if !logs.IsEmpty() {
-			s += "Logs:\n"
-			logsFocused := d.selectedTable == detailSelectedLogs
-			s += ViewLogs(logs, d.LogFields, d.Width, logsFocused)
+	if f, ok := d.flameGraph.Get(); ok {
+		footer := footerHelp(joinHelp("Back", d.Keys.Back, d.Keys.FlameGraph), d.Keys.ExportFolded)
+		if d.status != "" {
+			footer += " | " + d.status
 		}
-	})
+		return "Flame graph (widths are subtree totals, counting parallel children in full):\n" +
+			renderIcicle(f, d.width) + "\n\n" +
+			lipgloss.NewStyle().Foreground(theme.Muted).Render(footer) + "\n"
+	}
+
+	if i, ok := d.inspector.Get(); ok {
+		return i.View() + "\n"
+	}
 
-	style := lipgloss.NewStyle()
-	if d.focused {
-		style = style.BorderForeground(lipgloss.Color("63"))
+	if sql, ok := d.sqlSummary.Get(); ok {
+		return "SQL queries:\n" + renderSQLSummary(sql, d.width) + "\n" +
+			lipgloss.NewStyle().Foreground(theme.Muted).Render(footerHelp(joinHelp("Back", d.Keys.Back, d.Keys.SQL))) + "\n"
 	}
-	return style.Render(s)
+
+	if v, ok := d.logViewer.Get(); ok {
+		return v.View()
+	}
+
+	s := d.timelineSection()
+	d.logs.ForEach(func(logs logsTable) {
+		s += logs.Header() + "\n"
+		s += logs.View()
+	})
+	return s
+}
+
+// refreshViewport re-renders the pane content into the viewport. It has to
+// be called whenever anything that affects the content changes.
+func (d *DetailsPane) refreshViewport() {
+	d.viewport.SetContent(strings.TrimSuffix(d.content(), "\n"))
+}
+
+func (d DetailsPane) View() string {
+	return d.viewport.View()
 }
@@ -3,3 +3,9 @@ package ui
 type ErrorMsg struct {
 	Msg string
 }
+
+// StatusMsg reports a problem the session can carry on from, e.g. a trace
+// ID that wasn't found, in place of the help bar until the next key press.
+type StatusMsg struct {
+	Msg string
+}
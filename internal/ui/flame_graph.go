@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/zopu/tracey/internal/analysis"
+)
+
+type flameCell struct {
+	start, end int
+	node       analysis.FlameNode
+}
+
+// renderIcicle draws frames as an icicle graph: roots along the top, each
+// frame's children below it, and widths proportional to the frames' totals.
+// Frames narrower than a character are left out.
+func renderIcicle(frames []analysis.FlameNode, width int) string {
+	var total time.Duration
+	for _, f := range frames {
+		total += f.Total
+	}
+	if total == 0 || width <= 0 {
+		return "No spans to show"
+	}
+
+	rows := make([][]flameCell, 0)
+	var place func(frames []analysis.FlameNode, depth int, offset time.Duration)
+	place = func(frames []analysis.FlameNode, depth int, offset time.Duration) {
+		column := func(d time.Duration) int {
+			return int(float64(d)/float64(total)*float64(width) + 0.5)
+		}
+		for _, f := range frames {
+			start, end := column(offset), column(offset+f.Total)
+			if end > start {
+				if depth == len(rows) {
+					rows = append(rows, []flameCell{})
+				}
+				rows[depth] = append(rows[depth], flameCell{start: start, end: end, node: f})
+				place(f.Children, depth+1, offset)
+			}
+			offset += f.Total
+		}
+	}
+	place(frames, 0, 0)
+
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		var b strings.Builder
+		x := 0
+		for _, c := range row {
+			b.WriteString(strings.Repeat(" ", c.start-x))
+			b.WriteString(flameCellStyle(c.node.Name).Render(flameLabel(c.node, c.end-c.start)))
+			x = c.end
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// flameCellStyle picks a frame's colour by name, so a span keeps its colour
+// between graphs. Without colours, alternate frames are reversed instead.
+func flameCellStyle(name string) lipgloss.Style {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	if theme.Reverse {
+		return lipgloss.NewStyle().Reverse(h.Sum32()%2 == 0)
+	}
+	return lipgloss.NewStyle().
+		Background(theme.Flame[h.Sum32()%uint32(len(theme.Flame))]).
+		Foreground(theme.Surface)
+}
+
+// flameLabel fits a frame's name, and its total when there's room, into
+// width characters.
+func flameLabel(f analysis.FlameNode, width int) string {
+	label := f.Name + " " + formatLatency(f.Total)
+	if runewidth.StringWidth(label) > width {
+		label = f.Name
+	}
+	label = runewidth.Truncate(label, width, "…")
+	return label + strings.Repeat(" ", width-runewidth.StringWidth(label))
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/store"
+)
+
+// GotoTraceMsg asks for a trace to be fetched by ID and shown, even when
+// it isn't in the trace list.
+type GotoTraceMsg struct {
+	ID aws.TraceID
+}
+
+// FoundTraceMsg has a trace fetched by ID, with a summary made from its
+// segments for the trace list.
+type FoundTraceMsg struct {
+	Summary aws.TraceSummary
+	Details TraceDetailsMsg
+	// Why the trace's logs couldn't be queried, if they couldn't
+	LogsErr string
+}
+
+// GotoTrace fetches a trace with BatchGetTraces and adds it to the store.
+// The ID is typed in, so failures are reported without ending the session.
+func GotoTrace(st *store.Store, id aws.TraceID, logGroupNames []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		traces, err := aws.FetchTraceDetailsBatch(ctx, []aws.TraceID{id})
+		if err != nil {
+			return StatusMsg{Msg: err.Error()}
+		}
+		if len(traces) == 0 {
+			return StatusMsg{Msg: fmt.Sprintf("trace not found: %s", id)}
+		}
+		td := traces[0]
+		summary := td.Summary()
+		st.AddTraceSummaries([]aws.TraceSummary{summary})
+
+		found := FoundTraceMsg{Summary: summary, Details: TraceDetailsMsg{Trace: &td}}
+		if len(logGroupNames) > 0 {
+			// Show the trace without its logs
+			if found.Details.LogsQueryID, err = aws.StartLogsQuery(ctx, logGroupNames, id); err != nil {
+				found.LogsErr = "found the trace, but not its logs: " + err.Error()
+			}
+		}
+		return found
+	}
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/aws"
+)
+
+type GroupsMsg struct {
+	Groups []aws.Group
+}
+
+// FetchGroups gets the groups to pick from. Failures are reported without
+// ending the session, and the current group is kept.
+func FetchGroups() tea.Cmd {
+	return func() tea.Msg {
+		groups, err := aws.FetchGroups(context.Background())
+		if err != nil {
+			return StatusMsg{Msg: "couldn't load groups: " + err.Error()}
+		}
+		return GroupsMsg{Groups: groups}
+	}
+}
+
+// SelectGroupMsg scopes the trace list, service map and insights to a group.
+type SelectGroupMsg struct {
+	Group aws.Group
+}
+
+// GroupSelector is a popup list of X-Ray groups to pick from.
+type GroupSelector struct {
+	groups mo.Option[[]aws.Group]
+	cursor int
+	isOpen bool
+	// Name of the group currently selected
+	current string
+	width   int
+	height  int
+}
+
+func (s *GroupSelector) Open(current aws.Group) tea.Cmd {
+	s.isOpen = true
+	s.current = current.Name
+	s.groups = mo.None[[]aws.Group]()
+	return FetchGroups()
+}
+
+func (s GroupSelector) IsOpen() bool {
+	return s.isOpen
+}
+
+func (s *GroupSelector) SetFocus(bool) {}
+
+// IsCapturingInput is true while open, so no other keys get through.
+func (s *GroupSelector) IsCapturingInput() bool {
+	return s.isOpen
+}
+
+func (s *GroupSelector) SetSize(width, height int) {
+	s.width = width
+	s.height = height
+}
+
+func (s *GroupSelector) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case GroupsMsg:
+		s.groups = mo.Some(msg.Groups)
+		s.cursor = 0
+		for i, g := range msg.Groups {
+			if g.Name == s.current {
+				s.cursor = i
+			}
+		}
+	case StatusMsg:
+		// The groups couldn't be fetched, so there's nothing to pick from
+		if s.groups.IsAbsent() {
+			s.isOpen = false
+		}
+	case tea.KeyMsg:
+		groups := s.groups.OrEmpty()
+		switch msg.String() {
+		case "esc", "q":
+			s.isOpen = false
+		case "up", "k":
+			s.cursor = max(s.cursor-1, 0)
+		case "down", "j":
+			s.cursor = max(min(s.cursor+1, len(groups)-1), 0)
+		case "enter", " ":
+			if len(groups) == 0 {
+				return nil
+			}
+			s.isOpen = false
+			group := groups[s.cursor]
+			return func() tea.Msg {
+				return SelectGroupMsg{Group: group}
+			}
+		}
+	}
+	return nil
+}
+
+func (s GroupSelector) View() string {
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Select an X-Ray group") + "\n\n")
+
+	groups, ok := s.groups.Get()
+	switch {
+	case !ok:
+		b.WriteString("Loading groups...\n")
+	case len(groups) == 0:
+		b.WriteString("No groups found\n")
+	}
+	// Keep the cursor on screen, leaving room for the header and footer
+	rows := max(s.height-6, 1)
+	start := max(0, min(s.cursor-rows/2, len(groups)-rows))
+	for i := start; i < min(start+rows, len(groups)); i++ {
+		g := groups[i]
+		prefix, name := "  ", g.Name
+		if i == s.cursor {
+			prefix = "→ "
+			name = theme.Selected(lipgloss.NewStyle()).Render(name)
+		}
+		if g.Name == s.current {
+			name += " (current)"
+		}
+		filter := g.FilterExpression
+		if filter == "" {
+			filter = "all traces"
+		}
+		fmt.Fprintf(&b, "%s%s  %s\n", prefix, name, mutedStyle.Render(filter))
+	}
+	b.WriteString("\n" + mutedStyle.Render("Enter: Select group | Esc: Cancel"))
+	return b.String()
+}
@@ -1,19 +1,122 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// HelpBar shows the keys for the active pane, as many as fit on one line.
+// The last binding, for the full help, is always shown.
 type HelpBar struct {
-	Width int
+	Width    int
+	Bindings []key.Binding
+	// Shown instead of the bindings when set
+	Status string
 }
 
+const helpSeparator = " | "
+
 func (h HelpBar) Render() string {
 	style := lipgloss.NewStyle().
 		Width(h.Width).
-		Background(lipgloss.Color("#303446")).
-		Foreground(lipgloss.Color("#c6d0f5")).
+		MaxHeight(1).
+		Background(theme.Surface).
+		Foreground(theme.Text).
 		PaddingLeft(2).
 		PaddingRight(2)
+	if h.Status != "" {
+		return "\n" + style.Foreground(theme.Error).Render(h.Status)
+	}
+
+	items := make([]string, 0, len(h.Bindings))
+	for _, b := range h.Bindings {
+		if b.Enabled() && b.Help().Key != "" {
+			items = append(items, b.Help().Key+": "+b.Help().Desc)
+		}
+	}
+	if len(items) == 0 {
+		return "\n" + style.Render("")
+	}
+	last := items[len(items)-1]
+	available := h.Width - 4 - lipgloss.Width(last)
+	shown := make([]string, 0, len(items))
+	for _, item := range items[:len(items)-1] {
+		width := lipgloss.Width(item) + lipgloss.Width(helpSeparator)
+		if width > available {
+			break
+		}
+		shown = append(shown, item)
+		available -= width
+	}
+	return "\n" + style.Render(strings.Join(append(shown, last), helpSeparator))
+}
+
+// footerHelp describes keys for the footer of a view, e.g.
+// "f: Flame graph | e: Export folded stacks". Unbound keys are left out.
+func footerHelp(bindings ...key.Binding) string {
+	items := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		if b.Enabled() && b.Help().Key != "" {
+			items = append(items, b.Help().Key+": "+b.Help().Desc)
+		}
+	}
+	return strings.Join(items, helpSeparator)
+}
+
+// joinHelp combines the keys of bindings that do the same thing under one
+// description, e.g. "Esc/f: Back".
+func joinHelp(desc string, bindings ...key.Binding) key.Binding {
+	keys := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		if b.Enabled() && b.Help().Key != "" {
+			keys = append(keys, b.Help().Key)
+		}
+	}
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(keys, "/"), desc))
+}
+
+// withHelp describes a binding differently, for where it does something
+// more specific than its help says.
+func withHelp(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
+
+// HelpSection is a titled group of key bindings in the full help.
+type HelpSection struct {
+	Title   string
+	Columns [][]key.Binding
+}
+
+// RenderFullHelp lists every binding in each section, in columns.
+func RenderFullHelp(sections []HelpSection) string {
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	keyStyle := lipgloss.NewStyle().Foreground(theme.Accent)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
 
-	helpTxt := "↑/↓/j/k: Navigate Trace List | Enter: View details | Tab: Switch pane | q/Esc: Quit"
-	return "\n" + style.Render(helpTxt)
+	var b strings.Builder
+	for _, section := range sections {
+		b.WriteString(headerStyle.Render(section.Title) + "\n")
+		columns := make([]string, 0, len(section.Columns))
+		for _, bindings := range section.Columns {
+			keyWidth := 0
+			for _, binding := range bindings {
+				keyWidth = max(keyWidth, lipgloss.Width(binding.Help().Key))
+			}
+			lines := make([]string, 0, len(bindings))
+			for _, binding := range bindings {
+				if !binding.Enabled() || binding.Help().Key == "" {
+					continue
+				}
+				padding := strings.Repeat(" ", keyWidth-lipgloss.Width(binding.Help().Key))
+				lines = append(lines, keyStyle.Render(binding.Help().Key)+padding+"  "+binding.Help().Desc)
+			}
+			columns = append(columns, lipgloss.NewStyle().PaddingRight(4).Render(strings.Join(lines, "\n")))
+		}
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, columns...) + "\n\n")
+	}
+	b.WriteString(mutedStyle.Render("Esc/?: Close"))
+	return b.String()
 }
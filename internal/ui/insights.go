@@ -0,0 +1,345 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/keymap"
+)
+
+type InsightsMsg struct {
+	Query    aws.TraceQuery
+	Insights []aws.Insight
+}
+
+// FetchInsights gets the insights for the view. Insights are turned on per
+// group, so failures are reported without ending the session.
+func FetchInsights(query aws.TraceQuery) tea.Cmd {
+	return func() tea.Msg {
+		insights, err := aws.FetchInsights(context.Background(), query)
+		if err != nil {
+			return StatusMsg{Msg: "couldn't load insights: " + err.Error()}
+		}
+		return InsightsMsg{Query: query, Insights: insights}
+	}
+}
+
+type InsightDetailsMsg struct {
+	ID          string
+	Events      []aws.InsightEvent
+	ImpactGraph *aws.ServiceGraph
+}
+
+func FetchInsightDetails(insight aws.Insight) tea.Cmd {
+	return func() tea.Msg {
+		events, err := aws.FetchInsightEvents(context.Background(), insight.ID)
+		if err != nil {
+			return StatusMsg{Msg: "couldn't load the insight's events: " + err.Error()}
+		}
+		graph, err := aws.FetchInsightImpactGraph(context.Background(), insight)
+		if err != nil {
+			return StatusMsg{Msg: "couldn't load the insight's impacted services: " + err.Error()}
+		}
+		return InsightDetailsMsg{ID: insight.ID, Events: events, ImpactGraph: graph}
+	}
+}
+
+// InsightsView lists the X-Ray insights in the current time range. Opening
+// one shows its timeline and the services it affected, and any of those can
+// be used to look at the faulted traces while the insight was open.
+type InsightsView struct {
+	Keys keymap.InsightsKeys
+	// The query the insights were fetched for
+	query         aws.TraceQuery
+	insights      mo.Option[[]aws.Insight]
+	cursor        int
+	open          mo.Option[aws.Insight]
+	details       mo.Option[InsightDetailsMsg]
+	serviceCursor int
+	viewport      viewport.Model
+}
+
+func NewInsightsView() InsightsView {
+	return InsightsView{Keys: keymap.Default().Insights, viewport: viewport.New(0, 0)}
+}
+
+func (v *InsightsView) SetFocus(bool) {}
+
+func (v *InsightsView) IsCapturingInput() bool {
+	return false
+}
+
+func (v *InsightsView) SetSize(width, height int) {
+	v.viewport.Width = width
+	v.viewport.Height = height
+	v.refreshViewport()
+}
+
+func (v *InsightsView) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case InsightsMsg:
+		// Active insights first, then the most recent
+		insights := msg.Insights
+		sort.SliceStable(insights, func(i, j int) bool {
+			a, b := insights[i], insights[j]
+			if a.Active != b.Active {
+				return a.Active
+			}
+			return a.Start.After(b.Start)
+		})
+		v.query = msg.Query
+		v.insights = mo.Some(insights)
+		v.cursor = 0
+		v.open = mo.None[aws.Insight]()
+	case InsightDetailsMsg:
+		if insight, ok := v.open.Get(); ok && insight.ID == msg.ID {
+			v.details = mo.Some(msg)
+			v.serviceCursor = 0
+		}
+	case tea.KeyMsg:
+		if v.open.IsPresent() {
+			cmd = v.updateDetails(msg)
+		} else {
+			cmd = v.updateList(msg)
+		}
+	}
+	v.refreshViewport()
+	return cmd
+}
+
+func (v *InsightsView) updateList(msg tea.KeyMsg) tea.Cmd {
+	insights := v.insights.OrEmpty()
+	if len(insights) == 0 {
+		return nil
+	}
+	switch {
+	case key.Matches(msg, v.Keys.Up):
+		v.cursor = max(v.cursor-1, 0)
+	case key.Matches(msg, v.Keys.Down):
+		v.cursor = min(v.cursor+1, len(insights)-1)
+	case key.Matches(msg, v.Keys.Top):
+		v.cursor = 0
+	case key.Matches(msg, v.Keys.Bottom):
+		v.cursor = len(insights) - 1
+	case key.Matches(msg, v.Keys.Select):
+		insight := insights[v.cursor]
+		v.open = mo.Some(insight)
+		v.details = mo.None[InsightDetailsMsg]()
+		v.viewport.GotoTop()
+		return FetchInsightDetails(insight)
+	case key.Matches(msg, v.Keys.RootCause):
+		insight := insights[v.cursor]
+		return v.showTraces(insight, insight.RootCauseService)
+	}
+	return nil
+}
+
+func (v *InsightsView) updateDetails(msg tea.KeyMsg) tea.Cmd {
+	insight := v.open.MustGet()
+	services := v.impactedServices()
+	switch {
+	case key.Matches(msg, v.Keys.Back):
+		v.open = mo.None[aws.Insight]()
+		v.details = mo.None[InsightDetailsMsg]()
+	case key.Matches(msg, v.Keys.ScrollUp):
+		v.viewport.HalfViewUp()
+	case key.Matches(msg, v.Keys.ScrollDown):
+		v.viewport.HalfViewDown()
+	case key.Matches(msg, v.Keys.Up):
+		v.serviceCursor = max(v.serviceCursor-1, 0)
+	case key.Matches(msg, v.Keys.Down):
+		v.serviceCursor = max(min(v.serviceCursor+1, len(services)-1), 0)
+	case key.Matches(msg, v.Keys.Select):
+		if len(services) == 0 {
+			return nil
+		}
+		service := services[v.serviceCursor]
+		return v.showTraces(insight, aws.ServiceName{Name: service.Name, Type: service.Type})
+	case key.Matches(msg, v.Keys.RootCause):
+		return v.showTraces(insight, insight.RootCauseService)
+	}
+	return nil
+}
+
+func (v InsightsView) impactedServices() []aws.ServiceNode {
+	details, ok := v.details.Get()
+	if !ok || details.ImpactGraph == nil {
+		return nil
+	}
+	return details.ImpactGraph.Services
+}
+
+// showTraces shows the faulted traces through a service while the insight
+// was open.
+func (v InsightsView) showTraces(insight aws.Insight, service aws.ServiceName) tea.Cmd {
+	if service.Name == "" {
+		return nil
+	}
+	start, end := insight.Window()
+	query := aws.TraceQuery{
+		Start:  start,
+		End:    end,
+		Filter: serviceFilter(service) + " { fault }",
+		Group:  v.query.Group,
+	}
+	return func() tea.Msg {
+		return SetTraceQueryMsg{Query: query}
+	}
+}
+
+// serviceFilter is an X-Ray filter expression for a service, including its
+// type when known since names aren't unique across types.
+func serviceFilter(service aws.ServiceName) string {
+	if service.Type == "" {
+		return fmt.Sprintf("service(%q)", service.Name)
+	}
+	return fmt.Sprintf("service(id(name: %q, type: %q))", service.Name, service.Type)
+}
+
+func (v *InsightsView) refreshViewport() {
+	insights, ok := v.insights.Get()
+	if !ok {
+		v.viewport.SetContent("Loading insights...")
+		return
+	}
+	if len(insights) == 0 {
+		v.viewport.SetContent("No insights found in this time range")
+		return
+	}
+	if insight, ok := v.open.Get(); ok {
+		v.viewport.SetContent(v.renderDetails(insight))
+		return
+	}
+
+	var b strings.Builder
+	cursorStart, cursorEnd := 0, 0
+	for i, insight := range insights {
+		if i == v.cursor {
+			cursorStart = strings.Count(b.String(), "\n")
+		}
+		b.WriteString(renderInsight(insight, i == v.cursor))
+		b.WriteString("\n")
+		if i == v.cursor {
+			cursorEnd = strings.Count(b.String(), "\n")
+		}
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(theme.Muted).
+		Render(footerHelp(withHelp(v.Keys.Select, "Open insight"), v.Keys.RootCause)))
+	v.viewport.SetContent(b.String())
+
+	if cursorStart < v.viewport.YOffset {
+		v.viewport.SetYOffset(cursorStart)
+	} else if cursorEnd > v.viewport.YOffset+v.viewport.Height {
+		v.viewport.SetYOffset(cursorEnd - v.viewport.Height)
+	}
+}
+
+func renderInsight(insight aws.Insight, selected bool) string {
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	prefix := "  "
+	if selected {
+		prefix = "→ "
+		titleStyle = theme.Selected(titleStyle)
+	}
+	state := mutedStyle.Render("CLOSED")
+	if insight.Active {
+		state = lipgloss.NewStyle().Foreground(theme.Error).Bold(true).Render("ACTIVE")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s %s %s\n", prefix, state,
+		titleStyle.Render(insight.Summary),
+		mutedStyle.Render(strings.Join(insight.Categories, ", ")))
+	fmt.Fprintf(&b, "    %s\n", renderInsightWindow(insight))
+	if insight.RootCauseService.Name != "" {
+		fmt.Fprintf(&b, "    Root cause: %s  %s\n",
+			insight.RootCauseService, renderRequestImpact(insight.RootCauseImpact))
+	}
+	fmt.Fprintf(&b, "    Clients: %s\n", renderRequestImpact(insight.ClientImpact))
+	return b.String()
+}
+
+func renderInsightWindow(insight aws.Insight) string {
+	start, end := insight.Window()
+	endText := "now"
+	if e, ok := insight.End.Get(); ok {
+		endText = e.Local().Format("01-02 15:04")
+	}
+	return fmt.Sprintf("%s – %s (%s)",
+		start.Local().Format("01-02 15:04"), endText, end.Sub(start).Round(time.Minute))
+}
+
+func renderRequestImpact(impact aws.RequestImpact) string {
+	faults := fmt.Sprintf("%5.1f%% faults", impact.FaultRate()*100)
+	if impact.Faults > 0 {
+		faults = lipgloss.NewStyle().Foreground(theme.Error).Render(faults)
+	}
+	return fmt.Sprintf("%7d req  %s", impact.Total, faults)
+}
+
+func (v InsightsView) renderDetails(insight aws.Insight) string {
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+
+	var b strings.Builder
+	b.WriteString(renderInsight(insight, false))
+	b.WriteString("\n")
+
+	details, ok := v.details.Get()
+	if !ok {
+		b.WriteString("Loading insight details...")
+		return b.String()
+	}
+
+	b.WriteString(headerStyle.Render("Timeline") + "\n")
+	for _, event := range details.Events {
+		fmt.Fprintf(&b, "  %s  %s\n", mutedStyle.Render(event.Time.Local().Format("01-02 15:04:05")), event.Summary)
+		fmt.Fprintf(&b, "    Clients: %s  Root cause: %s\n",
+			renderRequestImpact(event.ClientImpact), renderRequestImpact(event.RootCauseImpact))
+		if len(event.TopAnomalousServices) > 0 {
+			names := lo.Map(event.TopAnomalousServices, func(s aws.ServiceName, _ int) string {
+				return s.String()
+			})
+			fmt.Fprintf(&b, "    Anomalous: %s\n", strings.Join(names, ", "))
+		}
+	}
+
+	b.WriteString("\n" + headerStyle.Render("Impacted services") + "\n")
+	for i, service := range v.impactedServices() {
+		prefix := "  "
+		name := service.Name
+		if i == v.serviceCursor {
+			prefix = "→ "
+			name = theme.Selected(lipgloss.NewStyle()).Render(name)
+		}
+		line := prefix + name
+		if service.Type != "" {
+			line += " " + mutedStyle.Render(service.Type)
+		}
+		if len(service.Edges) > 0 {
+			targets := lo.Map(service.Edges, func(e aws.ServiceEdge, _ int) string {
+				return e.TargetName
+			})
+			line += mutedStyle.Render(" ─▶ " + strings.Join(targets, ", "))
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n" + mutedStyle.Render(footerHelp(withHelp(v.Keys.Select, "Show faulted traces"), v.Keys.RootCause, v.Keys.Back)))
+	return b.String()
+}
+
+func (v InsightsView) View() string {
+	return v.viewport.View()
+}
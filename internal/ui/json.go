@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// formatLogMessage indents and colors a JSON log message. Anything that
+// isn't valid JSON is returned unchanged, so plain text log lines aren't
+// colored as if they were JSON tokens.
+func formatLogMessage(s string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(s), "", "  "); err != nil {
+		return s
+	}
+	return highlightJSON(buf.String())
+}
+
+// highlightJSON colors the tokens of an (indented) JSON document. It doesn't
+// validate its input; unrecognised characters are passed through as-is.
+func highlightJSON(s string) string {
+	var (
+		jsonKeyStyle     = lipgloss.NewStyle().Foreground(theme.Accent)
+		jsonStringStyle  = lipgloss.NewStyle().Foreground(theme.Success)
+		jsonNumberStyle  = lipgloss.NewStyle().Foreground(theme.Highlight)
+		jsonLiteralStyle = lipgloss.NewStyle().Foreground(theme.Literal)
+		jsonPunctStyle   = lipgloss.NewStyle().Foreground(theme.Punctuation)
+	)
+	var out strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(runes))
+			token := string(runes[i:end])
+			// A string followed by a colon is an object key
+			next := end
+			for next < len(runes) && runes[next] == ' ' {
+				next++
+			}
+			if next < len(runes) && runes[next] == ':' {
+				out.WriteString(jsonKeyStyle.Render(token))
+			} else {
+				out.WriteString(jsonStringStyle.Render(token))
+			}
+			i = end
+		case r == '-' || unicode.IsDigit(r):
+			end := i + 1
+			for end < len(runes) && strings.ContainsRune("0123456789.eE+-", runes[end]) {
+				end++
+			}
+			out.WriteString(jsonNumberStyle.Render(string(runes[i:end])))
+			i = end
+		case unicode.IsLetter(r):
+			end := i + 1
+			for end < len(runes) && unicode.IsLetter(runes[end]) {
+				end++
+			}
+			out.WriteString(jsonLiteralStyle.Render(string(runes[i:end])))
+			i = end
+		case strings.ContainsRune("{}[]:,", r):
+			out.WriteString(jsonPunctStyle.Render(string(r)))
+			i++
+		default:
+			out.WriteRune(r)
+			i++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/keymap"
+)
+
+const (
+	latencySectionHistogram = iota
+	latencySectionGroups
+)
+
+const histogramBarWidth = 40
+
+// LatencyView summarises the response times of all loaded traces, as a
+// histogram and grouped by path, method or status. Selecting a bucket or
+// group filters the trace list to the traces in it.
+type LatencyView struct {
+	Keys         keymap.LatencyKeys
+	traces       []aws.TraceSummary
+	histogram    []analysis.HistogramBucket
+	groups       []analysis.Group
+	groupKey     int
+	section      int
+	bucketCursor int
+	groupCursor  int
+	viewport     viewport.Model
+}
+
+func NewLatencyView() LatencyView {
+	return LatencyView{Keys: keymap.Default().Latency, viewport: viewport.New(0, 0)}
+}
+
+func (l *LatencyView) SetTraces(traces []aws.TraceSummary) {
+	l.traces = traces
+	l.histogram = analysis.LatencyHistogram(traces)
+	l.groups = analysis.GroupTraces(traces, analysis.GroupKeys[l.groupKey])
+	l.bucketCursor = min(l.bucketCursor, max(len(l.histogram)-1, 0))
+	l.groupCursor = min(l.groupCursor, max(len(l.groups)-1, 0))
+	l.refreshViewport()
+}
+
+func (l *LatencyView) SetFocus(bool) {}
+
+func (l *LatencyView) IsCapturingInput() bool {
+	return false
+}
+
+func (l *LatencyView) SetSize(width, height int) {
+	l.viewport.Width = width
+	l.viewport.Height = height
+	l.refreshViewport()
+}
+
+func (l *LatencyView) Update(msg tea.Msg) tea.Cmd {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return nil
+	}
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(keyMsg, l.Keys.NextSection):
+		l.section = (l.section + 1) % 2
+	case key.Matches(keyMsg, l.Keys.Up):
+		l.moveCursor(-1)
+	case key.Matches(keyMsg, l.Keys.Down):
+		l.moveCursor(1)
+	case key.Matches(keyMsg, l.Keys.GroupBy):
+		l.groupKey = (l.groupKey + 1) % len(analysis.GroupKeys)
+		l.groupCursor = 0
+		l.SetTraces(l.traces)
+	case key.Matches(keyMsg, l.Keys.Select):
+		cmd = l.selectionCmd()
+	case key.Matches(keyMsg, l.Keys.Profile):
+		cmd = l.profileCmd()
+	}
+	l.refreshViewport()
+	return cmd
+}
+
+func (l *LatencyView) moveCursor(amount int) {
+	if l.section == latencySectionHistogram {
+		l.bucketCursor = min(max(l.bucketCursor+amount, 0), max(len(l.histogram)-1, 0))
+		return
+	}
+	l.groupCursor = min(max(l.groupCursor+amount, 0), max(len(l.groups)-1, 0))
+}
+
+func (l LatencyView) selectionCmd() tea.Cmd {
+	var filter TraceFilter
+	switch {
+	case l.section == latencySectionHistogram && len(l.histogram) > 0:
+		bucket := l.histogram[l.bucketCursor]
+		filter = TraceFilter{
+			Description: "response time " + bucketLabel(bucket),
+			Match: func(t aws.TraceSummary) bool {
+				return bucket.Contains(t.ResponseTime())
+			},
+		}
+	case l.section == latencySectionGroups && len(l.groups) > 0:
+		key := analysis.GroupKeys[l.groupKey]
+		group := l.groups[l.groupCursor]
+		filter = TraceFilter{
+			Description: key.Name + " " + group.Key,
+			Match: func(t aws.TraceSummary) bool {
+				return key.Key(t) == group.Key
+			},
+		}
+	default:
+		return nil
+	}
+	return func() tea.Msg {
+		return FilterTracesMsg{Filter: filter}
+	}
+}
+
+// profileCmd profiles the spans of the traces in the selected bucket or group.
+func (l LatencyView) profileCmd() tea.Cmd {
+	var msg ProfileTracesMsg
+	switch {
+	case l.section == latencySectionHistogram && len(l.histogram) > 0:
+		bucket := l.histogram[l.bucketCursor]
+		msg = ProfileTracesMsg{
+			Description: "response time " + bucketLabel(bucket),
+			Traces: lo.Filter(l.traces, func(t aws.TraceSummary, _ int) bool {
+				return bucket.Contains(t.ResponseTime())
+			}),
+		}
+	case l.section == latencySectionGroups && len(l.groups) > 0:
+		group := l.groups[l.groupCursor]
+		msg = ProfileTracesMsg{
+			Description: analysis.GroupKeys[l.groupKey].Name + " " + group.Key,
+			Traces:      group.Traces,
+		}
+	default:
+		return nil
+	}
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+func bucketLabel(b analysis.HistogramBucket) string {
+	if b.High == 0 {
+		return "≥ " + formatLatency(b.Low)
+	}
+	return formatLatency(b.Low) + "–" + formatLatency(b.High)
+}
+
+func (l *LatencyView) refreshViewport() {
+	if len(l.traces) == 0 {
+		l.viewport.SetContent("No traces loaded yet")
+		return
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	selectedStyle := theme.Selected(lipgloss.NewStyle())
+	barStyle := lipgloss.NewStyle().Foreground(theme.Focus)
+
+	var b strings.Builder
+	stats := analysis.Summarize(l.traces)
+	fmt.Fprintf(&b, "%s  %s\n\n",
+		headerStyle.Render(fmt.Sprintf("Response times for %d traces", stats.Count)),
+		renderLatencyStats(stats))
+
+	b.WriteString(headerStyle.Render("Histogram") + "\n")
+	maxCount := 0
+	for _, bucket := range l.histogram {
+		maxCount = max(maxCount, bucket.Count)
+	}
+	cursorLine := 0
+	for i, bucket := range l.histogram {
+		line := fmt.Sprintf("%-14s %s %d",
+			bucketLabel(bucket),
+			barStyle.Render(strings.Repeat("█", max(bucket.Count*histogramBarWidth/maxCount, 1))),
+			bucket.Count)
+		b.WriteString(renderCursorLine(line, l.section == latencySectionHistogram && i == l.bucketCursor, selectedStyle))
+		if l.section == latencySectionHistogram && i == l.bucketCursor {
+			cursorLine = strings.Count(b.String(), "\n")
+		}
+	}
+
+	key := analysis.GroupKeys[l.groupKey]
+	fmt.Fprintf(&b, "\n%s %s\n",
+		headerStyle.Render("By "+key.Name),
+		mutedStyle.Render("("+footerHelp(withHelp(l.Keys.GroupBy, "group by "+analysis.GroupKeys[(l.groupKey+1)%len(analysis.GroupKeys)].Name))+")"))
+	fmt.Fprintf(&b, "  %7s  %7s  %7s  %7s  %7s  %s\n", "Count", "Errors", "p50", "p90", "p99", key.Name)
+	for i, group := range l.groups {
+		line := fmt.Sprintf("%7d  %6.1f%%  %7s  %7s  %7s  %s",
+			group.Stats.Count,
+			group.Stats.ErrorRate()*100,
+			formatLatency(group.Stats.P50),
+			formatLatency(group.Stats.P90),
+			formatLatency(group.Stats.P99),
+			group.Key)
+		b.WriteString(renderCursorLine(line, l.section == latencySectionGroups && i == l.groupCursor, selectedStyle))
+		if l.section == latencySectionGroups && i == l.groupCursor {
+			cursorLine = strings.Count(b.String(), "\n")
+		}
+	}
+	b.WriteString("\n" + mutedStyle.Render(footerHelp(l.Keys.NextSection, l.Keys.Select, l.Keys.Profile)))
+
+	l.viewport.SetContent(b.String())
+	if cursorLine <= l.viewport.YOffset {
+		l.viewport.SetYOffset(cursorLine - 1)
+	} else if cursorLine > l.viewport.YOffset+l.viewport.Height {
+		l.viewport.SetYOffset(cursorLine - l.viewport.Height)
+	}
+}
+
+func renderCursorLine(line string, selected bool, selectedStyle lipgloss.Style) string {
+	if selected {
+		return "→ " + selectedStyle.Render(line) + "\n"
+	}
+	return "  " + line + "\n"
+}
+
+func renderLatencyStats(stats analysis.LatencyStats) string {
+	parts := []string{
+		"p50 " + formatLatency(stats.P50),
+		"p90 " + formatLatency(stats.P90),
+		"p99 " + formatLatency(stats.P99),
+		"max " + formatLatency(stats.Max),
+		fmt.Sprintf("errors %.1f%%", stats.ErrorRate()*100),
+	}
+	return strings.Join(parts, "  ")
+}
+
+func (l LatencyView) View() string {
+	return l.viewport.View()
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+type logLevel int
+
+const (
+	logLevelUnknown logLevel = iota
+	logLevelDebug
+	logLevelInfo
+	logLevelWarn
+	logLevelError
+)
+
+var logLevelsBySeverity = []logLevel{logLevelError, logLevelWarn, logLevelInfo, logLevelDebug}
+
+func (l logLevel) String() string {
+	switch l {
+	case logLevelDebug:
+		return "debug"
+	case logLevelInfo:
+		return "info"
+	case logLevelWarn:
+		return "warn"
+	case logLevelError:
+		return "error"
+	case logLevelUnknown:
+	}
+	return "unknown"
+}
+
+func (l logLevel) Style() lipgloss.Style {
+	style := lipgloss.NewStyle()
+	switch l {
+	case logLevelDebug:
+		return style.Foreground(theme.Subtle)
+	case logLevelInfo:
+		return style.Foreground(theme.Text)
+	case logLevelWarn:
+		return style.Foreground(theme.Warning)
+	case logLevelError:
+		return style.Foreground(theme.Error)
+	case logLevelUnknown:
+	}
+	return style
+}
+
+// nextMinLevel cycles through the minimum level filter: everything, then
+// debug and above up to errors only.
+func (l logLevel) nextMinLevel() logLevel {
+	if l == logLevelError {
+		return logLevelUnknown
+	}
+	return l + 1
+}
+
+// parseLogLevel maps the many spellings of log severities onto our levels.
+// Numeric levels follow the bunyan/pino convention (30 = info).
+func parseLogLevel(v any) logLevel {
+	s := strings.ToLower(strings.TrimSpace(fmt.Sprint(v)))
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		switch {
+		case n >= 50:
+			return logLevelError
+		case n >= 40:
+			return logLevelWarn
+		case n >= 30:
+			return logLevelInfo
+		default:
+			return logLevelDebug
+		}
+	}
+	switch s {
+	case "error", "err", "fatal", "critical", "crit", "panic", "alert", "emergency", "emerg", "severe":
+		return logLevelError
+	case "warn", "warning":
+		return logLevelWarn
+	case "info", "information", "notice":
+		return logLevelInfo
+	case "debug", "trace", "verbose", "fine":
+		return logLevelDebug
+	}
+	return logLevelUnknown
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samber/lo"
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/config"
+	"github.com/zopu/tracey/internal/store"
+)
+
+// The most traces a log search loads.
+const maxLogSearchTraces = 100
+
+// SearchLogsMsg asks for the traces of log events containing a term.
+type SearchLogsMsg struct {
+	Term string
+	// How far back to search. By default, the trace list's time range.
+	Window time.Duration
+}
+
+// LogSearchMsg has the traces found in the log events matching a search.
+type LogSearchMsg struct {
+	Term   string
+	Events int
+	Traces []aws.TraceSummary
+}
+
+// SearchLogsForTraces runs a Logs Insights query over log groups, finds the
+// trace IDs in the matching events and fetches those traces with
+// BatchGetTraces, adding them to the store. Failures are reported without
+// ending the session.
+func SearchLogsForTraces(
+	st *store.Store,
+	logGroupNames []string,
+	term string,
+	start, end time.Time,
+	logs config.Logs,
+) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		messages, err := aws.SearchLogs(ctx, logGroupNames, term, start, end)
+		if err != nil {
+			return StatusMsg{Msg: err.Error()}
+		}
+		ids := analysis.TraceIDsInLogs(messages, logs)
+		ids = ids[:min(len(ids), maxLogSearchTraces)]
+		traces, err := aws.FetchTraceDetailsBatch(ctx, ids)
+		if err != nil {
+			return StatusMsg{Msg: err.Error()}
+		}
+		summaries := lo.Map(traces, func(td aws.TraceDetails, _ int) aws.TraceSummary { return td.Summary() })
+		st.AddTraceSummaries(summaries)
+		return LogSearchMsg{Term: term, Events: len(messages), Traces: summaries}
+	}
+}
@@ -5,11 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/evertras/bubble-table/table"
@@ -17,6 +17,7 @@ import (
 	"github.com/samber/lo"
 	"github.com/zopu/tracey/internal/aws"
 	"github.com/zopu/tracey/internal/config"
+	"github.com/zopu/tracey/internal/keymap"
 )
 
 type TraceLogsMsg struct {
@@ -34,53 +35,107 @@ func FetchLogs(id aws.LogQueryID, delay time.Duration) tea.Cmd {
 	}
 }
 
-//nolint:gocognit // Work in progress
-func ViewLogs(logs aws.LogData, fields []config.ParsedLogField, tableWidth int, focused bool) string {
-	if len(fields) == 0 {
-		return ""
+// LogGroupsMsg has the log groups to query in a region.
+type LogGroupsMsg struct {
+	Region string
+	Groups []string
+}
+
+// FetchLogGroups finds the log groups matching the config in a region, after
+// switching to it.
+func FetchLogGroups(region string, patterns []string) tea.Cmd {
+	return func() tea.Msg {
+		groups, err := aws.MatchingLogGroups(context.Background(), patterns)
+		if err != nil {
+			return StatusMsg{Msg: "logs won't be shown for " + region + ": " + err.Error()}
+		}
+		return LogGroupsMsg{Region: region, Groups: groups}
 	}
+}
+
+const (
+	// Row data key holding the raw @message of each log event. It isn't a
+	// column so it's never rendered in the table itself.
+	logsMessageKey = "@message"
+	logsLevelKey   = "@level"
+	logsPageSize   = 10
+)
+
+type logsTable struct {
+	tableModel table.Model
+	fields     []config.ParsedLogField
+	// The widest value seen in each column, before the table is fitted to the pane
+	contentWidths []int
+	allRows       []table.Row
+	levelCounts   map[logLevel]int
+	minLevel      logLevel
+	keys          keymap.DetailsKeys
+}
+
+func newLogsTable(
+	logs aws.LogData,
+	fields []config.ParsedLogField,
+	levelQuery *gojq.Query,
+	tableWidth int,
+	keys keymap.DetailsKeys,
+) logsTable {
+	tableKeys := scrollableTableKeyMap()
+	tableKeys.Filter = keys.FilterLogs
 
 	widths := lo.Map(fields, func(f config.ParsedLogField, _ int) int {
 		return len(f.Title)
 	})
 
 	rows := make([]table.Row, 0)
+	levelCounts := map[logLevel]int{}
 	for _, event := range logs.Results.Results {
 		for _, field := range event {
-			if *field.Field == "@message" { //nolint:nestif //Work in progress
-				var unmarshalled map[string]any
-				err := json.Unmarshal([]byte(*field.Value), &unmarshalled)
-				if err != nil {
-					log.Fatalf("failed to unmarshal json: %v", err)
-				}
-				row := make(table.RowData, len(fields))
-				for i, field := range fields {
-					it := field.Query.Run(unmarshalled)
-					for {
-						v, ok := it.Next()
-						if !ok {
-							break
-						}
-						if jqErr, aok := v.(error); aok {
-							if errors.Is(jqErr, &gojq.HaltError{}) {
-								break
-							}
-							log.Fatalln(jqErr)
-						}
-						rowKey := strconv.Itoa(i)
-						val := strings.TrimSpace(fmt.Sprintf("%#s", v))
-						row[rowKey] = val
-						width := len(val)
-						if widths[i] < width {
-							widths[i] = width
-						}
-					}
+			if field.Field == nil || field.Value == nil || *field.Field != "@message" {
+				continue
+			}
+			row := logEventRow(*field.Value, fields, levelQuery)
+			for i := range fields {
+				if val, ok := row[strconv.Itoa(i)].(string); ok && widths[i] < len(val) {
+					widths[i] = len(val)
 				}
-				rows = append(rows, table.NewRow(row))
 			}
+			level, _ := row[logsLevelKey].(logLevel)
+			levelCounts[level]++
+			rows = append(rows, table.NewRow(row).WithStyle(level.Style()))
 		}
 	}
 
+	t := table.New(logsColumns(fields, widths, tableWidth)).
+		WithTargetWidth(tableWidth).
+		WithRows(rows).
+		WithMultiline(true).
+		WithPageSize(logsPageSize).
+		Filtered(true).
+		WithKeyMap(tableKeys).
+		WithBaseStyle(
+			lipgloss.NewStyle().
+				Foreground(theme.Text).
+				BorderForeground(theme.Muted).
+				Bold(false)).
+		HeaderStyle(
+			lipgloss.NewStyle().
+				Bold(true)).
+		HighlightStyle(
+			theme.Selected(lipgloss.NewStyle().
+				Foreground(theme.Text)))
+	return logsTable{
+		tableModel:    t,
+		fields:        fields,
+		contentWidths: widths,
+		allRows:       rows,
+		levelCounts:   levelCounts,
+		keys:          keys,
+	}
+}
+
+func logsColumns(fields []config.ParsedLogField, contentWidths []int, tableWidth int) []table.Column {
+	widths := append([]int{}, contentWidths...)
+
 	// Extend last column to fill the width of the table
 	totalWidth := 0
 	for _, w := range widths {
@@ -90,31 +145,184 @@ func ViewLogs(logs aws.LogData, fields []config.ParsedLogField, tableWidth int,
 		widths[len(widths)-1] += tableWidth - totalWidth - 4
 	}
 
-	columns := lo.Map(fields, func(f config.ParsedLogField, i int) table.Column {
-		return table.NewColumn(strconv.Itoa(i), f.Title, widths[i])
+	return lo.Map(fields, func(f config.ParsedLogField, i int) table.Column {
+		return table.NewColumn(strconv.Itoa(i), f.Title, widths[i]).WithFiltered(true)
 	})
+}
 
-	borderColor := lipgloss.Color("240")
-	if focused {
-		borderColor = lipgloss.Color("63")
+// WithSize lays the table out for a new pane width, showing pageSize rows
+// per page.
+func (l logsTable) WithSize(width, pageSize int) logsTable {
+	l.tableModel = l.tableModel.
+		WithColumns(logsColumns(l.fields, l.contentWidths, width)).
+		WithTargetWidth(width).
+		WithPageSize(pageSize)
+	return l
+}
+
+// logEventRow runs each field query against a single @message. Messages that
+// aren't JSON are shown verbatim in the last column.
+func logEventRow(message string, fields []config.ParsedLogField, levelQuery *gojq.Query) table.RowData {
+	row := make(table.RowData, len(fields)+2)
+	row[logsMessageKey] = message
+	row[logsLevelKey] = logLevelUnknown
+
+	var unmarshalled map[string]any
+	if err := json.Unmarshal([]byte(message), &unmarshalled); err != nil {
+		row[strconv.Itoa(len(fields)-1)] = strings.TrimSpace(message)
+		return row
+	}
+	for i, field := range fields {
+		if v, ok := runLogQuery(field.Query, unmarshalled); ok {
+			row[strconv.Itoa(i)] = strings.TrimSpace(fmt.Sprintf("%#s", v))
+		}
+	}
+	if levelQuery != nil {
+		if v, ok := runLogQuery(*levelQuery, unmarshalled); ok {
+			row[logsLevelKey] = parseLogLevel(v)
+		}
 	}
+	return row
+}
 
-	t := table.New(columns).
-		WithTargetWidth(tableWidth).
-		WithRows(rows).
-		WithMultiline(true).
-		WithBaseStyle(
-			lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#c6d0f5")).
-				BorderForeground(borderColor).
-				Bold(false)).
-		HeaderStyle(
-			lipgloss.NewStyle().
-				Bold(true)).
-		HighlightStyle(
-			lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#c6d0f5")).
-				Background(lipgloss.Color("#414559")))
-	s := t.View() + "\n"
+// runLogQuery returns the last value produced by a jq query. Errors are
+// returned as the value so they show up in the table.
+func runLogQuery(query gojq.Query, event map[string]any) (any, bool) {
+	var result any
+	found := false
+	it := query.Run(event)
+	for {
+		v, ok := it.Next()
+		if !ok {
+			break
+		}
+		if jqErr, aok := v.(error); aok {
+			if errors.Is(jqErr, &gojq.HaltError{}) {
+				break
+			}
+			v = jqErr.Error()
+		}
+		result = v
+		found = true
+	}
+	return result, found
+}
+
+func (l logsTable) Update(msg tea.Msg) (logsTable, tea.Cmd) {
+	switch msg := msg.(type) { //nolint:gocritic // standard pattern
+	case tea.KeyMsg:
+		if key.Matches(msg, l.keys.LogLevel) && !l.IsFiltering() {
+			l.minLevel = l.minLevel.nextMinLevel()
+			l.tableModel = l.tableModel.WithRows(l.visibleRows())
+			return l, nil
+		}
+		var cmd tea.Cmd
+		l.tableModel, cmd = l.tableModel.Update(msg)
+		return l, cmd
+	}
+	return l, nil
+}
+
+func (l logsTable) SetFocus(focus bool) logsTable {
+	l.tableModel = l.tableModel.WithBaseStyle(
+		lipgloss.NewStyle().BorderForeground(theme.Border(focus)).
+			Foreground(theme.Text).
+			Bold(false)).
+		Focused(focus)
+	return l
+}
+
+// visibleRows are the rows at or above the minimum level. Events without a
+// level are only hidden once a minimum level is chosen.
+func (l logsTable) visibleRows() []table.Row {
+	if l.minLevel == logLevelUnknown {
+		return l.allRows
+	}
+	return lo.Filter(l.allRows, func(row table.Row, _ int) bool {
+		level, _ := row.Data[logsLevelKey].(logLevel)
+		return level >= l.minLevel
+	})
+}
+
+// IsFiltering is true while the user is typing a text filter, when keys
+// should go to the filter input rather than be treated as commands.
+func (l logsTable) IsFiltering() bool {
+	return l.tableModel.GetIsFilterInputFocused()
+}
+
+// Header summarises the log levels and any filters applied.
+func (l logsTable) Header() string {
+	s := "Logs:"
+	counts := make([]string, 0, len(logLevelsBySeverity))
+	for _, level := range logLevelsBySeverity {
+		if n := l.levelCounts[level]; n > 0 {
+			counts = append(counts, level.Style().Render(fmt.Sprintf("%d %s", n, level)))
+		}
+	}
+	if len(counts) > 0 {
+		s += " " + strings.Join(counts, " · ")
+	}
+	if l.minLevel != logLevelUnknown {
+		s += fmt.Sprintf(" (showing %s and above)", l.minLevel)
+	}
+	if f := l.tableModel.GetCurrentFilter(); f != "" {
+		s += fmt.Sprintf(" (matching %q)", f)
+	}
 	return s
 }
+
+// HighlightedMessage returns the raw @message of the row under the cursor.
+func (l logsTable) HighlightedMessage() (string, bool) {
+	msg, ok := l.tableModel.HighlightedRow().Data[logsMessageKey].(string)
+	return msg, ok
+}
+
+func (l logsTable) View() string {
+	return l.tableModel.View() + "\n"
+}
+
+type LogCopiedMsg struct {
+	Err error
+}
+
+// logViewer shows a single log message in full.
+type logViewer struct {
+	message string
+	status  string
+	keys    keymap.DetailsKeys
+}
+
+func newLogViewer(message string, keys keymap.DetailsKeys) logViewer {
+	return logViewer{message: message, keys: keys}
+}
+
+func (v logViewer) Update(msg tea.Msg) (logViewer, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key.Matches(msg, v.keys.CopyLog) {
+			message := v.message
+			return v, func() tea.Msg {
+				return LogCopiedMsg{Err: writeClipboard(message)}
+			}
+		}
+	case LogCopiedMsg:
+		v.status = "Copied to clipboard"
+		if msg.Err != nil {
+			v.status = "Copy failed: " + msg.Err.Error()
+		}
+	}
+	return v, nil
+}
+
+func (v logViewer) View() string {
+	footer := footerHelp(
+		joinHelp("Close", v.keys.Back, v.keys.ViewLog),
+		withHelp(v.keys.CopyLog, "Copy"),
+		joinHelp("Scroll", v.keys.ScrollUp, v.keys.ScrollDown))
+	if v.status != "" {
+		footer += " | " + v.status
+	}
+	return "Log message:\n" +
+		formatLogMessage(v.message) + "\n\n" +
+		lipgloss.NewStyle().Foreground(theme.Muted).Render(footer) + "\n"
+}
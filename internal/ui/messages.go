@@ -1,3 +1,33 @@
 package ui
 
+import (
+	"time"
+
+	"github.com/zopu/tracey/internal/aws"
+)
+
 type SelectNextPaneMsg struct{}
+
+// SetTraceFilterMsg asks for the trace list to be refetched with a new
+// X-Ray filter expression. An empty filter clears it.
+type SetTraceFilterMsg struct {
+	Filter string
+}
+
+// SetTraceQueryMsg asks for the trace list to be refetched over a new time
+// range as well as with a new filter expression.
+type SetTraceQueryMsg struct {
+	Query aws.TraceQuery
+}
+
+// SetTraceWindowMsg asks for the trace list to look back over a new length
+// of time up to now, which is kept when the filter or group changes.
+type SetTraceWindowMsg struct {
+	Window time.Duration
+}
+
+// SwitchRegionMsg asks for everything to be fetched again from another
+// region.
+type SwitchRegionMsg struct {
+	Region string
+}
@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/fuzzy"
+	"github.com/zopu/tracey/internal/keymap"
+)
+
+// PaletteCommand is an action that can be run from the command palette.
+type PaletteCommand struct {
+	Name string
+	// Keys that run it without the palette
+	Keys string
+	// Words that run it straight away with an argument, e.g. "goto <ID>"
+	Aliases []string
+	// What to prompt for as its argument, if it takes one
+	Arg string
+	// Run makes the message that carries out the command
+	Run func(arg string) (tea.Msg, error)
+}
+
+// recentCommands is how many recently run commands are listed first.
+const recentCommands = 5
+
+// CommandPalette lists every command, fuzzy matched against what's typed,
+// and prompts for their arguments. Opened with ":".
+type CommandPalette struct {
+	Keys     keymap.PaletteKeys
+	input    textinput.Model
+	commands []PaletteCommand
+	matches  []PaletteCommand
+	cursor   int
+	// Names of the commands run most recently, most recent first
+	recent []string
+	// The command whose argument is being typed
+	prompting mo.Option[PaletteCommand]
+	status    string
+	isOpen    bool
+	width     int
+	height    int
+}
+
+func NewCommandPalette() CommandPalette {
+	input := textinput.New()
+	// Blink messages aren't routed to the palette
+	input.Cursor.SetMode(cursor.CursorStatic)
+	return CommandPalette{Keys: keymap.Default().Palette, input: input}
+}
+
+func (p *CommandPalette) Open(commands []PaletteCommand) {
+	p.commands = commands
+	p.isOpen = true
+	p.status = ""
+	p.listCommands()
+}
+
+func (p CommandPalette) IsOpen() bool {
+	return p.isOpen
+}
+
+func (p *CommandPalette) SetFocus(bool) {}
+
+// IsCapturingInput is true while open, so no other keys get through.
+func (p *CommandPalette) IsCapturingInput() bool {
+	return p.isOpen
+}
+
+func (p *CommandPalette) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.input.Width = max(width-4, 0)
+}
+
+func (p *CommandPalette) Update(msg tea.Msg) tea.Cmd {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return nil
+	}
+	switch {
+	case key.Matches(keyMsg, p.Keys.Close):
+		if p.prompting.IsPresent() {
+			p.listCommands()
+		} else {
+			p.isOpen = false
+		}
+		return nil
+	case key.Matches(keyMsg, p.Keys.Up):
+		p.cursor = max(p.cursor-1, 0)
+		return nil
+	case key.Matches(keyMsg, p.Keys.Down):
+		p.cursor = max(min(p.cursor+1, len(p.matches)-1), 0)
+		return nil
+	case key.Matches(keyMsg, p.Keys.Run):
+		return p.enter()
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	p.status = ""
+	if p.prompting.IsAbsent() {
+		p.filter()
+	}
+	return cmd
+}
+
+func (p *CommandPalette) enter() tea.Cmd {
+	if c, ok := p.prompting.Get(); ok {
+		return p.run(c, p.input.Value())
+	}
+	// A command's alias runs it with the rest as its argument, e.g. "goto 1-...".
+	// Without an argument, the input is only a search, since aliases like "r"
+	// are also the start of other commands.
+	word, arg, _ := strings.Cut(strings.TrimSpace(p.input.Value()), " ")
+	if c, ok := lo.Find(p.commands, func(c PaletteCommand) bool {
+		return lo.Contains(c.Aliases, word)
+	}); ok && strings.TrimSpace(arg) != "" {
+		return p.run(c, arg)
+	}
+	if len(p.matches) == 0 {
+		p.status = fmt.Sprintf("no command matching %q", p.input.Value())
+		return nil
+	}
+	c := p.matches[p.cursor]
+	if c.Arg != "" {
+		p.prompt(c)
+		return nil
+	}
+	return p.run(c, "")
+}
+
+func (p *CommandPalette) run(c PaletteCommand, arg string) tea.Cmd {
+	msg, err := c.Run(strings.TrimSpace(arg))
+	if err != nil {
+		p.status = err.Error()
+		return nil
+	}
+	p.isOpen = false
+	p.recent = append([]string{c.Name}, lo.Without(p.recent, c.Name)...)
+	p.recent = p.recent[:min(len(p.recent), recentCommands)]
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+// listCommands goes back to the list of commands, with nothing typed.
+func (p *CommandPalette) listCommands() {
+	p.prompting = mo.None[PaletteCommand]()
+	p.input.Prompt = "> "
+	p.input.Placeholder = "Type to search commands"
+	p.input.SetValue("")
+	p.input.Focus()
+	p.filter()
+}
+
+func (p *CommandPalette) prompt(c PaletteCommand) {
+	p.prompting = mo.Some(c)
+	p.input.Prompt = c.Name + ": "
+	p.input.Placeholder = c.Arg
+	p.input.SetValue("")
+	p.status = ""
+}
+
+// filter lists the commands matching what's typed, best first, with the
+// recently run ones ahead of others that match as well.
+func (p *CommandPalette) filter() {
+	ordered := make([]PaletteCommand, 0, len(p.commands))
+	for _, name := range p.recent {
+		if c, ok := lo.Find(p.commands, func(c PaletteCommand) bool { return c.Name == name }); ok {
+			ordered = append(ordered, c)
+		}
+	}
+	ordered = append(ordered, lo.Filter(p.commands, func(c PaletteCommand, _ int) bool {
+		return !lo.Contains(p.recent, c.Name)
+	})...)
+	names := lo.Map(ordered, func(c PaletteCommand, _ int) string { return c.Name })
+	p.matches = lo.Map(fuzzy.Filter(p.input.Value(), names), func(i int, _ int) PaletteCommand {
+		return ordered[i]
+	})
+	p.cursor = 0
+}
+
+func (p CommandPalette) View() string {
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Commands") + "\n\n")
+	b.WriteString(p.input.View() + "\n")
+	if p.status != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(theme.Error).Render(p.status))
+	}
+	b.WriteString("\n")
+
+	if p.prompting.IsPresent() {
+		b.WriteString(mutedStyle.Render(footerHelp(p.Keys.Run, withHelp(p.Keys.Close, "Back"))))
+		return b.String()
+	}
+	nameWidth := lo.Max(lo.Map(p.matches, func(c PaletteCommand, _ int) int {
+		return runewidth.StringWidth(c.Name)
+	}))
+	// Keep the cursor on screen, leaving room for the header and footer
+	rows := max(p.height-7, 1)
+	start := max(0, min(p.cursor-rows/2, len(p.matches)-rows))
+	for i := start; i < min(start+rows, len(p.matches)); i++ {
+		c := p.matches[i]
+		prefix, name := "  ", runewidth.FillRight(c.Name, nameWidth)
+		if i == p.cursor {
+			prefix = "→ "
+			name = theme.Selected(lipgloss.NewStyle()).Render(name)
+		}
+		keys := c.Keys
+		if keys == "" && len(c.Aliases) > 0 {
+			keys = ":" + strings.Join(c.Aliases, "/")
+		}
+		if p.input.Value() == "" && lo.Contains(p.recent, c.Name) {
+			keys += " (recent)"
+		}
+		fmt.Fprintf(&b, "%s%s  %s\n", prefix, name, mutedStyle.Render(strings.TrimSpace(keys)))
+	}
+	if len(p.matches) == 0 {
+		b.WriteString("No matching commands\n")
+	}
+	b.WriteString("\n" + mutedStyle.Render(footerHelp(p.Keys.Run, joinHelp("Move", p.Keys.Up, p.Keys.Down), p.Keys.Close)))
+	return b.String()
+}
@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/keymap"
+)
+
+// DefaultProfileTraces is how many traces are fetched for a span profile
+// unless configured otherwise.
+const DefaultProfileTraces = 50
+
+const profileBarWidth = 20
+
+var nonSlugChars = regexp.MustCompile(`[^0-9A-Za-z]+`)
+
+// ProfileTracesMsg asks for the spans of some traces to be profiled.
+type ProfileTracesMsg struct {
+	Description string
+	Traces      []aws.TraceSummary
+}
+
+type SpanProfileMsg struct {
+	Description string
+	Traces      int
+	Profiles    []analysis.SpanProfile
+	// The traces' spans merged into one flame graph
+	Flame []analysis.FlameNode
+}
+
+// FetchSpanProfile gets the traces to profile. Failures are reported
+// without ending the session.
+func FetchSpanProfile(description string, ids []aws.TraceID) tea.Cmd {
+	return func() tea.Msg {
+		traces, err := aws.FetchTraceDetailsBatch(context.Background(), ids)
+		if err != nil {
+			return StatusMsg{Msg: "couldn't profile " + description + ": " + err.Error()}
+		}
+		return SpanProfileMsg{
+			Description: description,
+			Traces:      len(traces),
+			Profiles:    analysis.ProfileSpans(traces),
+			Flame:       analysis.FlameGraph(traces...),
+		}
+	}
+}
+
+// profileRoute profiles the traces with the same method and normalized path
+// as a trace.
+func profileRoute(trace aws.TraceSummary, traces []aws.TraceSummary) ProfileTracesMsg {
+	route := func(t aws.TraceSummary) string {
+		return t.Method() + " " + analysis.NormalizePath(t.Operation())
+	}
+	r := route(trace)
+	return ProfileTracesMsg{
+		Description: r,
+		Traces: lo.Filter(traces, func(t aws.TraceSummary, _ int) bool {
+			return route(t) == r
+		}),
+	}
+}
+
+// ProfileView shows where time goes across the traces of a route, with the
+// spans of every trace aggregated by name.
+type ProfileView struct {
+	Keys        keymap.ProfileKeys
+	description string
+	// Number of traces being fetched, while the profile loads
+	fetching int
+	profile  mo.Option[SpanProfileMsg]
+	cursor   int
+	// Showing the flame graph rather than the table
+	flame    bool
+	status   string
+	viewport viewport.Model
+}
+
+func NewProfileView() ProfileView {
+	return ProfileView{Keys: keymap.Default().Profile, viewport: viewport.New(0, 0)}
+}
+
+// Start clears the view while a new profile is fetched.
+func (v *ProfileView) Start(description string, traces int) {
+	v.description = description
+	v.fetching = traces
+	v.profile = mo.None[SpanProfileMsg]()
+	v.cursor = 0
+	v.status = ""
+	v.refreshViewport()
+}
+
+func (v *ProfileView) SetFocus(bool) {}
+
+func (v *ProfileView) IsCapturingInput() bool {
+	return false
+}
+
+func (v *ProfileView) SetSize(width, height int) {
+	v.viewport.Width = width
+	v.viewport.Height = height
+	v.refreshViewport()
+}
+
+func (v *ProfileView) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case SpanProfileMsg:
+		if msg.Description != v.description {
+			return nil
+		}
+		v.profile = mo.Some(msg)
+		v.cursor = 0
+	case ExportedMsg:
+		v.status = msg.Status()
+	case tea.KeyMsg:
+		profile, ok := v.profile.Get()
+		if !ok {
+			return nil
+		}
+		switch {
+		case key.Matches(msg, v.Keys.Up):
+			v.cursor = max(v.cursor-1, 0)
+		case key.Matches(msg, v.Keys.Down):
+			v.cursor = min(v.cursor+1, max(len(profile.Profiles)-1, 0))
+		case key.Matches(msg, v.Keys.PageUp):
+			v.cursor = max(v.cursor-v.viewport.Height, 0)
+		case key.Matches(msg, v.Keys.PageDown):
+			v.cursor = min(v.cursor+v.viewport.Height, max(len(profile.Profiles)-1, 0))
+		case key.Matches(msg, v.Keys.FlameGraph):
+			v.flame = !v.flame
+			v.viewport.GotoTop()
+		case key.Matches(msg, v.Keys.Export):
+			return exportFile(profileExportPath(profile.Description), analysis.FoldedStacks(profile.Flame))
+		}
+	}
+	v.refreshViewport()
+	return nil
+}
+
+func (v *ProfileView) refreshViewport() {
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	selectedStyle := theme.Selected(lipgloss.NewStyle())
+	barStyle := lipgloss.NewStyle().Foreground(theme.Focus)
+
+	if v.description == "" {
+		v.viewport.SetContent("Press p on a trace or a latency group to profile its spans")
+		return
+	}
+	profile, ok := v.profile.Get()
+	if !ok {
+		v.viewport.SetContent(fmt.Sprintf("Fetching %d traces for %s...", v.fetching, v.description))
+		return
+	}
+
+	flame := v.Keys.FlameGraph
+	if v.flame {
+		flame = withHelp(flame, "Span table")
+	}
+	footer := footerHelp(flame, v.Keys.Export)
+	if v.status != "" {
+		footer += " | " + v.status
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", headerStyle.Render(
+		fmt.Sprintf("Span profile for %s across %d traces", profile.Description, profile.Traces)))
+	if v.flame {
+		b.WriteString(mutedStyle.Render("Widths are subtree totals, counting parallel children in full") + "\n")
+		b.WriteString(renderIcicle(profile.Flame, v.viewport.Width) + "\n\n" + mutedStyle.Render(footer))
+		v.viewport.SetContent(b.String())
+		return
+	}
+	fmt.Fprintf(&b, "  %7s  %7s  %8s  %8s  %8s  %-*s  %s\n",
+		"Count", "Traces", "Mean", "p95", "Total", profileBarWidth+8, "Share", "Span")
+	cursorLine := 0
+	for i, p := range profile.Profiles {
+		bar := strings.Repeat("█", int(min(p.Share, 1)*profileBarWidth))
+		line := fmt.Sprintf("%7d  %7d  %8s  %8s  %8s  %6.1f%% %s  %s",
+			p.Count,
+			p.Traces,
+			formatLatency(p.Mean),
+			formatLatency(p.P95),
+			formatLatency(p.Total),
+			p.Share*100,
+			barStyle.Render(fmt.Sprintf("%-*s", profileBarWidth, bar)),
+			p.Name)
+		b.WriteString(renderCursorLine(line, i == v.cursor, selectedStyle))
+		if i == v.cursor {
+			cursorLine = strings.Count(b.String(), "\n")
+		}
+	}
+	if len(profile.Profiles) == 0 {
+		b.WriteString("  No subsegments in these traces\n")
+	}
+	b.WriteString("\n" + mutedStyle.Render("Share is of the traces' total time; nested spans count towards their parents too") + "\n")
+	b.WriteString(mutedStyle.Render(footer))
+
+	v.viewport.SetContent(b.String())
+	if cursorLine <= v.viewport.YOffset {
+		v.viewport.SetYOffset(cursorLine - 1)
+	} else if cursorLine > v.viewport.YOffset+v.viewport.Height {
+		v.viewport.SetYOffset(cursorLine - v.viewport.Height)
+	}
+}
+
+// profileExportPath names an export after what was profiled, e.g.
+// profile-GET-users-id.folded.
+func profileExportPath(description string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(description, "-"), "-")
+	return "profile-" + slug + ".folded"
+}
+
+func (v ProfileView) View() string {
+	return v.viewport.View()
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/config"
+)
+
+// RuleMatchesMsg has the rules matched by each trace checked, including
+// traces that matched none.
+type RuleMatchesMsg struct {
+	Matches map[string][]analysis.RuleMatch
+}
+
+// RuleCheckFailedMsg has the traces whose details couldn't be fetched to
+// check them, e.g. when throttled, so they can be checked again later.
+type RuleCheckFailedMsg struct {
+	IDs []aws.TraceID
+	Err string
+}
+
+// DefaultRuleTraces is how many traces of each query the rules are run over
+// unless configured otherwise.
+const DefaultRuleTraces = 500
+
+// CheckTraceRules fetches the details of traces and runs the configured
+// rules over them.
+func CheckTraceRules(rules []config.Rule, ids []aws.TraceID) tea.Cmd {
+	return func() tea.Msg {
+		traces, err := aws.FetchTraceDetailsBatch(context.Background(), ids)
+		if err != nil {
+			return RuleCheckFailedMsg{IDs: ids, Err: err.Error()}
+		}
+		matches := make(map[string][]analysis.RuleMatch, len(ids))
+		for _, id := range ids {
+			matches[string(id)] = nil
+		}
+		for _, td := range traces {
+			matches[string(td.ID)] = analysis.CheckRules(td, rules)
+		}
+		return RuleMatchesMsg{Matches: matches}
+	}
+}
+
+var severitySymbols = map[string]string{
+	config.SeverityInfo:    "ℹ",
+	config.SeverityWarning: "⚠",
+	config.SeverityError:   "✖",
+}
+
+func severityStyle(severity string) lipgloss.Style {
+	switch severity {
+	case config.SeverityError:
+		return lipgloss.NewStyle().Foreground(theme.Error)
+	case config.SeverityWarning:
+		return lipgloss.NewStyle().Foreground(theme.Warning)
+	default:
+		return lipgloss.NewStyle().Foreground(theme.Accent)
+	}
+}
+
+// ruleBadges counts a trace's matches by severity, most severe first, e.g.
+// "✖1 ⚠2".
+func ruleBadges(matches []analysis.RuleMatch) string {
+	badges := make([]string, 0, 3)
+	for _, severity := range []string{config.SeverityError, config.SeverityWarning, config.SeverityInfo} {
+		n := 0
+		for _, m := range matches {
+			if m.Severity == severity {
+				n++
+			}
+		}
+		if n > 0 {
+			badges = append(badges, fmt.Sprintf("%s%d", severitySymbols[severity], n))
+		}
+	}
+	return strings.Join(badges, " ")
+}
+
+// ruleAnnotation describes a match on a timeline row.
+func ruleAnnotation(m analysis.RuleMatch) string {
+	return fmt.Sprintf("%s %s (%s)", severitySymbols[m.Severity], m.Message, m.Rule)
+}
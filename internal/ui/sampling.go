@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/keymap"
+)
+
+type SamplingMsg struct {
+	Rules      []aws.SamplingRule
+	Statistics map[string]aws.SamplingStatistics
+}
+
+// FetchSampling gets the sampling rules and their statistics. Failures are
+// reported without ending the session.
+func FetchSampling() tea.Cmd {
+	return func() tea.Msg {
+		rules, err := aws.FetchSamplingRules(context.Background())
+		if err != nil {
+			return StatusMsg{Msg: "couldn't load sampling rules: " + err.Error()}
+		}
+		stats, err := aws.FetchSamplingStatistics(context.Background())
+		if err != nil {
+			return StatusMsg{Msg: "couldn't load sampling statistics: " + err.Error()}
+		}
+		return SamplingMsg{Rules: rules, Statistics: stats}
+	}
+}
+
+// SamplingView lists the sampling rules in the order X-Ray applies them,
+// marking the one that matches a request. The request starts out as the one
+// that began the highlighted trace, and can be edited.
+type SamplingView struct {
+	Keys     keymap.SamplingKeys
+	sampling mo.Option[SamplingMsg]
+	request  aws.SamplingRequest
+	input    textinput.Model
+	editing  bool
+	viewport viewport.Model
+}
+
+func NewSamplingView() SamplingView {
+	input := textinput.New()
+	input.Prompt = "Request: "
+	input.Placeholder = "service=api method=GET path=/users host=example.com"
+	// Blink messages aren't routed to this view
+	input.Cursor.SetMode(cursor.CursorStatic)
+	return SamplingView{Keys: keymap.Default().Sampling, input: input, viewport: viewport.New(0, 0)}
+}
+
+func (v *SamplingView) SetRequest(req aws.SamplingRequest) {
+	v.request = req
+	v.refreshViewport()
+}
+
+func (v *SamplingView) SetFocus(bool) {}
+
+func (v *SamplingView) IsCapturingInput() bool {
+	return v.editing
+}
+
+func (v *SamplingView) SetSize(width, height int) {
+	v.viewport.Width = width
+	// Leave room for the request line
+	v.viewport.Height = max(height-2, 0)
+	v.input.Width = max(width-12, 0)
+	v.refreshViewport()
+}
+
+func (v *SamplingView) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case SamplingMsg:
+		v.sampling = mo.Some(msg)
+	case tea.KeyMsg:
+		if v.editing {
+			switch msg.String() {
+			case "enter":
+				v.request = parseSamplingRequest(v.input.Value())
+				v.editing = false
+				v.input.Blur()
+			case "esc":
+				v.editing = false
+				v.input.Blur()
+			default:
+				v.input, cmd = v.input.Update(msg)
+			}
+			break
+		}
+		switch {
+		case key.Matches(msg, v.Keys.Edit):
+			v.editing = true
+			v.input.SetValue(formatSamplingRequest(v.request))
+			v.input.CursorEnd()
+			cmd = v.input.Focus()
+		case key.Matches(msg, v.Keys.Up):
+			v.viewport.LineUp(1)
+		case key.Matches(msg, v.Keys.Down):
+			v.viewport.LineDown(1)
+		}
+	}
+	v.refreshViewport()
+	return cmd
+}
+
+var samplingRequestFields = []struct {
+	key   string
+	field func(*aws.SamplingRequest) *string
+}{
+	{"service", func(r *aws.SamplingRequest) *string { return &r.ServiceName }},
+	{"type", func(r *aws.SamplingRequest) *string { return &r.ServiceType }},
+	{"host", func(r *aws.SamplingRequest) *string { return &r.Host }},
+	{"method", func(r *aws.SamplingRequest) *string { return &r.HTTPMethod }},
+	{"path", func(r *aws.SamplingRequest) *string { return &r.URLPath }},
+}
+
+func formatSamplingRequest(req aws.SamplingRequest) string {
+	parts := make([]string, 0, len(samplingRequestFields))
+	for _, f := range samplingRequestFields {
+		if value := *f.field(&req); value != "" {
+			parts = append(parts, f.key+"="+value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseSamplingRequest reads "key=value" pairs, ignoring unknown keys.
+func parseSamplingRequest(s string) aws.SamplingRequest {
+	var req aws.SamplingRequest
+	for _, part := range strings.Fields(s) {
+		key, value, _ := strings.Cut(part, "=")
+		for _, f := range samplingRequestFields {
+			if f.key == key {
+				*f.field(&req) = value
+			}
+		}
+	}
+	return req
+}
+
+func (v *SamplingView) refreshViewport() {
+	sampling, ok := v.sampling.Get()
+	if !ok {
+		v.viewport.SetContent("Loading sampling rules...")
+		return
+	}
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	matchStyle := theme.Selected(lipgloss.NewStyle()).Bold(true)
+
+	var b strings.Builder
+	match, matched := aws.MatchSamplingRule(sampling.Rules, v.request).Get()
+	if matched {
+		fmt.Fprintf(&b, "Matches %s: the first %d request(s) each second, then %g%% of the rest\n\n",
+			lipgloss.NewStyle().Bold(true).Render(match.Name), match.ReservoirSize, match.FixedRate*100)
+	} else {
+		b.WriteString("No sampling rule matches\n\n")
+	}
+
+	fmt.Fprintf(&b, "  %-24s %8s %9s %7s  %-16s %-20s %-16s %-6s %-20s %9s %9s\n",
+		"Name", "Priority", "Reservoir", "Rate", "Service", "Type", "Host", "Method", "Path", "Requests", "Sampled")
+	for _, r := range sampling.Rules {
+		stats := sampling.Statistics[r.Name]
+		line := fmt.Sprintf("%-24s %8d %7d/s %6g%%  %-16s %-20s %-16s %-6s %-20s %9d %9d",
+			r.Name, r.Priority, r.ReservoirSize, r.FixedRate*100,
+			r.ServiceName, r.ServiceType, r.Host, r.HTTPMethod, r.URLPath,
+			stats.Requests, stats.Sampled)
+		if matched && r.Name == match.Name {
+			b.WriteString("→ " + matchStyle.Render(line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+		if len(r.Attributes) > 0 {
+			b.WriteString(mutedStyle.Render(fmt.Sprintf("    attributes: %v (never matched here)", r.Attributes)) + "\n")
+		}
+	}
+	b.WriteString("\n" + mutedStyle.Render(footerHelp(v.Keys.Edit)+helpSeparator+"Statistics cover the last few minutes"))
+	v.viewport.SetContent(b.String())
+}
+
+func (v SamplingView) View() string {
+	request := "Request: " + formatSamplingRequest(v.request)
+	if v.editing {
+		request = v.input.View()
+	} else if v.request == (aws.SamplingRequest{}) {
+		request = "Request: any (" + footerHelp(withHelp(v.Keys.Edit, "Edit")) + ")"
+	}
+	return request + "\n\n" + v.viewport.View()
+}
@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/keymap"
+)
+
+type ServiceGraphMsg struct {
+	Query aws.TraceQuery
+	Graph *aws.ServiceGraph
+}
+
+// FetchServiceGraph gets the service graph for the view. Failures, e.g.
+// without permission to get it, are reported without ending the session.
+func FetchServiceGraph(query aws.TraceQuery) tea.Cmd {
+	return func() tea.Msg {
+		graph, err := aws.FetchServiceGraph(context.Background(), query)
+		if err != nil {
+			return StatusMsg{Msg: "couldn't load the service map: " + err.Error()}
+		}
+		return ServiceGraphMsg{Query: query, Graph: graph}
+	}
+}
+
+// ServiceMap lists the services in the service graph along with the calls
+// each one makes. Selecting a service filters the trace list to it.
+type ServiceMap struct {
+	Keys     keymap.ServiceMapKeys
+	graph    mo.Option[aws.ServiceGraph]
+	cursor   int
+	viewport viewport.Model
+}
+
+func NewServiceMap() ServiceMap {
+	return ServiceMap{Keys: keymap.Default().ServiceMap, viewport: viewport.New(0, 0)}
+}
+
+func (s *ServiceMap) SetFocus(bool) {}
+
+func (s *ServiceMap) IsCapturingInput() bool {
+	return false
+}
+
+func (s *ServiceMap) SetSize(width, height int) {
+	s.viewport.Width = width
+	s.viewport.Height = height
+	s.refreshViewport()
+}
+
+func (s *ServiceMap) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case ServiceGraphMsg:
+		graph := *msg.Graph
+		// Entry points first, then the busiest services
+		sort.SliceStable(graph.Services, func(i, j int) bool {
+			a, b := graph.Services[i], graph.Services[j]
+			if a.Root != b.Root {
+				return a.Root
+			}
+			return a.Stats.Total > b.Stats.Total
+		})
+		s.graph = mo.Some(graph)
+		s.cursor = 0
+	case tea.KeyMsg:
+		graph, ok := s.graph.Get()
+		if !ok {
+			return nil
+		}
+		switch {
+		case key.Matches(msg, s.Keys.Up):
+			s.cursor = max(s.cursor-1, 0)
+		case key.Matches(msg, s.Keys.Down):
+			s.cursor = min(s.cursor+1, len(graph.Services)-1)
+		case key.Matches(msg, s.Keys.Top):
+			s.cursor = 0
+		case key.Matches(msg, s.Keys.Bottom):
+			s.cursor = len(graph.Services) - 1
+		case key.Matches(msg, s.Keys.Select):
+			if len(graph.Services) == 0 {
+				return nil
+			}
+			filter := fmt.Sprintf("service(%q)", graph.Services[s.cursor].Name)
+			return func() tea.Msg {
+				return SetTraceFilterMsg{Filter: filter}
+			}
+		}
+	}
+	s.refreshViewport()
+	return nil
+}
+
+// refreshViewport renders the graph and scrolls to keep the cursor in view.
+func (s *ServiceMap) refreshViewport() {
+	graph, ok := s.graph.Get()
+	if !ok {
+		s.viewport.SetContent("Loading service graph...")
+		return
+	}
+	if len(graph.Services) == 0 {
+		s.viewport.SetContent("No services found in this time range")
+		return
+	}
+
+	var b strings.Builder
+	cursorStart, cursorEnd := 0, 0
+	for i, node := range graph.Services {
+		if i == s.cursor {
+			cursorStart = strings.Count(b.String(), "\n")
+		}
+		b.WriteString(s.renderNode(node, i == s.cursor))
+		if i == s.cursor {
+			cursorEnd = strings.Count(b.String(), "\n")
+		}
+	}
+	s.viewport.SetContent(strings.TrimSuffix(b.String(), "\n"))
+
+	if cursorStart < s.viewport.YOffset {
+		s.viewport.SetYOffset(cursorStart)
+	} else if cursorEnd > s.viewport.YOffset+s.viewport.Height {
+		s.viewport.SetYOffset(cursorEnd - s.viewport.Height)
+	}
+}
+
+func (s ServiceMap) renderNode(node aws.ServiceNode, selected bool) string {
+	nameStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	prefix := "  "
+	if selected {
+		prefix = "→ "
+		nameStyle = theme.Selected(nameStyle)
+	}
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+
+	var b strings.Builder
+	title := nameStyle.Render(node.Name)
+	if node.Type != "" {
+		title += " " + mutedStyle.Render(node.Type)
+	}
+	fmt.Fprintf(&b, "%s%s\n", prefix, title)
+	if node.Stats.Total > 0 {
+		fmt.Fprintf(&b, "    %s\n", renderRequestStats(node.Stats))
+	}
+	for i, edge := range node.Edges {
+		branch := "├─▶ "
+		if i == len(node.Edges)-1 {
+			branch = "└─▶ "
+		}
+		name := edge.TargetName
+		if name == "" {
+			name = fmt.Sprintf("(service %d)", edge.TargetID)
+		}
+		fmt.Fprintf(&b, "    %s%-30s %s\n", mutedStyle.Render(branch), name, renderRequestStats(edge.Stats))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func renderRequestStats(stats aws.RequestStats) string {
+	rate := func(label string, r float64, color lipgloss.TerminalColor) string {
+		s := fmt.Sprintf("%s %5.1f%%", label, r*100)
+		if r > 0 {
+			return lipgloss.NewStyle().Foreground(color).Render(s)
+		}
+		return s
+	}
+	return strings.Join([]string{
+		fmt.Sprintf("%7d req", stats.Total),
+		rate("err", stats.ErrorRate(), theme.Warning),
+		rate("fault", stats.FaultRate(), theme.Error),
+		rate("throttle", stats.ThrottleRate(), theme.Highlight),
+		fmt.Sprintf("p50 %6s", formatLatency(stats.Percentile(50))),
+		fmt.Sprintf("p90 %6s", formatLatency(stats.Percentile(90))),
+		fmt.Sprintf("p99 %6s", formatLatency(stats.Percentile(99))),
+	}, "  ")
+}
+
+func formatLatency(d time.Duration) string {
+	if d >= time.Second {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return formatMillis(d)
+}
+
+func (s ServiceMap) View() string {
+	return s.viewport.View()
+}
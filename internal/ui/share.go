@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/keymap"
+)
+
+// ShareTraceMsg asks for the share menu to be opened for a trace.
+type ShareTraceMsg struct {
+	ID aws.TraceID
+}
+
+// OpenTraceMsg asks for a trace to be opened in the CloudWatch console.
+type OpenTraceMsg struct {
+	ID aws.TraceID
+}
+
+type CopiedMsg struct {
+	What string
+	Err  error
+}
+
+// TraceLinks are the ways to share a trace.
+type TraceLinks struct {
+	ID          aws.TraceID
+	ConsoleURL  string
+	LogsURL     string
+	LogsEnabled bool
+}
+
+// writeClipboard copies to the local clipboard or, over SSH or where there
+// isn't one, to the terminal's clipboard with an OSC52 escape sequence. The
+// sequence goes straight to the terminal rather than stdout, where it could
+// land in the middle of a frame being rendered.
+func writeClipboard(text string) error {
+	if os.Getenv("SSH_TTY") == "" && !clipboard.Unsupported {
+		if err := clipboard.WriteAll(text); err == nil {
+			return nil
+		}
+	}
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	tty, err := openTerminal()
+	if err != nil {
+		return fmt.Errorf("failed to open the terminal to copy, %w", err)
+	}
+	defer tty.Close()
+	_, err = seq.WriteTo(tty)
+	return err
+}
+
+func openTerminal() (*os.File, error) {
+	if runtime.GOOS == "windows" {
+		return os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	}
+	return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+}
+
+func CopyToClipboard(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		return CopiedMsg{What: what, Err: writeClipboard(text)}
+	}
+}
+
+// CopySegmentJSON copies the raw segment documents of a trace. Failures are
+// reported without ending the session.
+func CopySegmentJSON(id aws.TraceID) tea.Cmd {
+	return func() tea.Msg {
+		td, err := aws.FetchTraceDetails(context.Background(), id)
+		if err != nil {
+			return StatusMsg{Msg: "couldn't copy the segment JSON: " + err.Error()}
+		}
+		docs := lo.Map(td.Segments, func(s aws.Segment, _ int) map[string]any { return s.Document })
+		data, err := json.MarshalIndent(docs, "", "  ")
+		if err != nil {
+			return StatusMsg{Msg: "couldn't copy the segment JSON: " + err.Error()}
+		}
+		return CopiedMsg{What: "segment JSON", Err: writeClipboard(string(data))}
+	}
+}
+
+// OpenURL opens a URL with the system browser. Where there isn't one, e.g.
+// over SSH, that's reported without ending the session.
+func OpenURL(url string) tea.Cmd {
+	return func() tea.Msg {
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", url)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+		default:
+			cmd = exec.Command("xdg-open", url)
+		}
+		if err := cmd.Start(); err != nil {
+			return StatusMsg{Msg: fmt.Sprintf("failed to open browser, %s", err)}
+		}
+		go cmd.Wait() //nolint:errcheck // The browser outlives this
+		return nil
+	}
+}
+
+// ShareMenu copies a trace's ID, console links and segments, or opens the
+// links in a browser.
+type ShareMenu struct {
+	Keys   keymap.ShareKeys
+	links  TraceLinks
+	status string
+	isOpen bool
+}
+
+func NewShareMenu() ShareMenu {
+	return ShareMenu{Keys: keymap.Default().Share}
+}
+
+func (s *ShareMenu) Open(links TraceLinks) {
+	s.links = links
+	s.status = ""
+	s.isOpen = true
+}
+
+func (s ShareMenu) IsOpen() bool {
+	return s.isOpen
+}
+
+func (s *ShareMenu) SetFocus(bool) {}
+
+// IsCapturingInput is true while open, so no other keys get through.
+func (s *ShareMenu) IsCapturingInput() bool {
+	return s.isOpen
+}
+
+func (s *ShareMenu) SetSize(int, int) {}
+
+func (s *ShareMenu) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case CopiedMsg:
+		s.status = "Copied " + msg.What
+		if msg.Err != nil {
+			s.status = "Copy failed: " + msg.Err.Error()
+		}
+	case StatusMsg:
+		s.status = msg.Msg
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, s.Keys.Close):
+			s.isOpen = false
+		case key.Matches(msg, s.Keys.CopyID):
+			return CopyToClipboard("trace ID", string(s.links.ID))
+		case key.Matches(msg, s.Keys.CopyURL):
+			return CopyToClipboard("console URL", s.links.ConsoleURL)
+		case key.Matches(msg, s.Keys.CopyLogsURL):
+			if s.links.LogsEnabled {
+				return CopyToClipboard("Logs Insights URL", s.links.LogsURL)
+			}
+		case key.Matches(msg, s.Keys.CopyJSON):
+			s.status = "Fetching segments..."
+			return CopySegmentJSON(s.links.ID)
+		case key.Matches(msg, s.Keys.OpenConsole):
+			return OpenURL(s.links.ConsoleURL)
+		case key.Matches(msg, s.Keys.OpenLogs):
+			if s.links.LogsEnabled {
+				return OpenURL(s.links.LogsURL)
+			}
+		}
+	}
+	return nil
+}
+
+func (s ShareMenu) View() string {
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	keyStyle := lipgloss.NewStyle().Foreground(theme.Accent)
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Share trace "+string(s.links.ID)) + "\n\n")
+	// Unbound keys aren't listed
+	item := func(binding key.Binding, detail string) {
+		if !binding.Enabled() {
+			return
+		}
+		fmt.Fprintf(&b, "%s  %-26s %s\n", keyStyle.Render(fmt.Sprintf("%-3s", binding.Help().Key)),
+			binding.Help().Desc, mutedStyle.Render(detail))
+	}
+	item(s.Keys.CopyID, string(s.links.ID))
+	item(s.Keys.CopyURL, s.links.ConsoleURL)
+	if s.links.LogsEnabled {
+		item(s.Keys.CopyLogsURL, s.links.LogsURL)
+	}
+	item(s.Keys.CopyJSON, "")
+	item(s.Keys.OpenConsole, "")
+	if s.links.LogsEnabled {
+		item(s.Keys.OpenLogs, "")
+	}
+	b.WriteString("\n" + mutedStyle.Render(footerHelp(s.Keys.Close)))
+	if s.status != "" {
+		b.WriteString(mutedStyle.Render(" | " + s.status))
+	}
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+	"github.com/zopu/tracey/internal/analysis"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/keymap"
+)
+
+// AddFilterClauseMsg asks for a clause to be added to the current X-Ray
+// filter expression.
+type AddFilterClauseMsg struct {
+	Clause string
+}
+
+// spanInspector shows everything recorded on one span, including its
+// annotations and metadata. Annotations can be picked to filter traces by.
+type spanInspector struct {
+	span   analysis.SpanNode
+	keys   []string
+	cursor int
+	keyMap keymap.InspectorKeys
+	// The details pane's keys that close the inspector, for its help
+	back key.Binding
+}
+
+func newSpanInspector(span analysis.SpanNode, keyMap keymap.InspectorKeys, back key.Binding) spanInspector {
+	keys := lo.Keys(span.Annotations)
+	slices.Sort(keys)
+	return spanInspector{span: span, keys: keys, keyMap: keyMap, back: back}
+}
+
+func (s spanInspector) Update(msg tea.KeyMsg) (spanInspector, tea.Cmd) {
+	switch {
+	case key.Matches(msg, s.keyMap.AddFilter):
+		if len(s.keys) == 0 {
+			return s, nil
+		}
+		annotation := s.keys[s.cursor]
+		clause := aws.AnnotationFilter(annotation, s.span.Annotations[annotation])
+		return s, func() tea.Msg {
+			return AddFilterClauseMsg{Clause: clause}
+		}
+	case key.Matches(msg, s.keyMap.Up):
+		s.cursor = max(s.cursor-1, 0)
+	case key.Matches(msg, s.keyMap.Down):
+		s.cursor = min(s.cursor+1, max(len(s.keys)-1, 0))
+	}
+	return s, nil
+}
+
+func (s spanInspector) View() string {
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	selectedStyle := theme.Selected(lipgloss.NewStyle())
+
+	n := s.span
+	var b strings.Builder
+	kind := "Subsegment"
+	if n.Segment {
+		kind = "Segment"
+	}
+	fmt.Fprintf(&b, "%s %s\n", headerStyle.Render(kind+" "+n.Name), mutedStyle.Render(n.ID))
+	if n.Type != "" {
+		fmt.Fprintf(&b, "Type: %s\n", n.Type)
+	}
+	fmt.Fprintf(&b, "Start: %s  Duration: %s\n", formatMillis(n.Offset), formatMillis(n.Duration))
+	flags := make([]string, 0, 3)
+	for name, set := range map[string]bool{"error": n.Error, "fault": n.Fault, "throttle": n.Throttle} {
+		if set {
+			flags = append(flags, name)
+		}
+	}
+	if len(flags) > 0 {
+		slices.Sort(flags)
+		fmt.Fprintf(&b, "Flags: %s\n", strings.Join(flags, ", "))
+	}
+	n.SQL.ForEach(func(sql aws.SQL) {
+		fmt.Fprintf(&b, "SQL: %s\n", analysis.NormalizeWhitespace(sql.SanitizedQuery))
+	})
+
+	b.WriteString("\n" + headerStyle.Render("Annotations") + "\n")
+	if len(s.keys) == 0 {
+		b.WriteString("  None\n")
+	}
+	for i, key := range s.keys {
+		line := fmt.Sprintf("%s = %v", key, n.Annotations[key])
+		b.WriteString(renderCursorLine(line, i == s.cursor, selectedStyle))
+	}
+
+	b.WriteString("\n" + headerStyle.Render("Metadata") + "\n")
+	if len(n.Metadata) == 0 {
+		b.WriteString("  None\n")
+	} else if doc, err := json.MarshalIndent(n.Metadata, "", "  "); err == nil {
+		b.WriteString(highlightJSON(string(doc)) + "\n")
+	}
+
+	b.WriteString("\n" + mutedStyle.Render(footerHelp(
+		s.back, joinHelp("Select annotation", s.keyMap.Up, s.keyMap.Down), s.keyMap.AddFilter)))
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/zopu/tracey/internal/analysis"
+)
+
+// renderSQLSummary lists the connections a trace queried and its statements,
+// slowest first, flagging likely N+1 queries.
+func renderSQLSummary(s analysis.SQLSummary, width int) string {
+	if len(s.Statements) == 0 {
+		return "No SQL queries in this trace"
+	}
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	warningStyle := lipgloss.NewStyle().Foreground(theme.Error).Bold(true)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d queries, %d statements, %s in total\n\n",
+		s.Count(), len(s.Statements), formatLatency(s.Total()))
+
+	b.WriteString(headerStyle.Render("Connections") + "\n")
+	b.WriteString(mutedStyle.Render(fmt.Sprintf("  %7s  %8s  %-12s  %-12s  %s", "Queries", "Total", "Database", "User", "URL")) + "\n")
+	for _, c := range s.Connections {
+		fmt.Fprintf(&b, "  %7d  %8s  %-12s  %-12s  %s\n",
+			c.Count, formatLatency(c.Total), orDash(c.DatabaseType), orDash(c.User), orDash(c.URL))
+	}
+
+	b.WriteString("\n" + headerStyle.Render("Statements") + "\n")
+	b.WriteString(mutedStyle.Render(fmt.Sprintf("  %7s  %8s  %8s  %s", "Count", "Total", "Avg", "Statement")) + "\n")
+	// Statements wrap under the statement column
+	indent := strings.Repeat(" ", 31)
+	queryWidth := max(width-len(indent), 20)
+	for _, st := range s.Statements {
+		query := st.Query
+		if query == "" {
+			query = "(no query)"
+		}
+		lines := wrapText(query, queryWidth)
+		fmt.Fprintf(&b, "  %7d  %8s  %8s  %s\n", st.Count, formatLatency(st.Total), formatLatency(st.Mean()), lines[0])
+		for _, line := range lines[1:] {
+			b.WriteString(indent + line + "\n")
+		}
+		if st.IsNPlusOne() {
+			b.WriteString(indent + warningStyle.Render(
+				fmt.Sprintf("⚠ Likely N+1: ran %d times in a row under %s", st.Repeats, st.RepeatedIn)) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// wrapText breaks text into lines of at most width characters, at spaces
+// where possible.
+func wrapText(text string, width int) []string {
+	lines := make([]string, 0)
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for runewidth.StringWidth(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			head := runewidth.Truncate(word, width, "")
+			lines = append(lines, head)
+			word = word[len(head):]
+		}
+		switch {
+		case line == "":
+			line = word
+		case runewidth.StringWidth(line)+1+runewidth.StringWidth(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	return append(lines, line)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samber/lo"
+)
+
+// Theme is the palette the UI is drawn with.
+type Theme struct {
+	Text lipgloss.TerminalColor
+	// Secondary text, borders of unfocused panes and help
+	Muted lipgloss.TerminalColor
+	// Text less important than muted, e.g. debug logs
+	Subtle lipgloss.TerminalColor
+	// The help bar and the trace list's cursor
+	Surface lipgloss.TerminalColor
+	// The selected row in lists
+	Selection lipgloss.TerminalColor
+	// Borders of the focused pane and bars in charts
+	Focus lipgloss.TerminalColor
+
+	Error     lipgloss.TerminalColor
+	Warning   lipgloss.TerminalColor
+	Success   lipgloss.TerminalColor
+	Highlight lipgloss.TerminalColor
+	Accent    lipgloss.TerminalColor
+	// JSON literals and punctuation
+	Literal     lipgloss.TerminalColor
+	Punctuation lipgloss.TerminalColor
+	// Frames in flame graphs
+	Flame []lipgloss.TerminalColor
+
+	// Selections are shown in reverse video rather than with a background,
+	// for themes without colors or where backgrounds are hard to see
+	Reverse bool
+}
+
+// DefaultTheme is used when the config doesn't pick one.
+const DefaultTheme = "catppuccin-frappe"
+
+// NoColorTheme is used when NO_COLOR is set, whatever the config says.
+const NoColorTheme = "no-color"
+
+func catppuccin(text, subtle, punctuation, surface, selection, red, yellow, green, peach, blue, mauve, pink, maroon string) Theme {
+	return Theme{
+		Text:        lipgloss.Color(text),
+		Muted:       lipgloss.Color("240"),
+		Subtle:      lipgloss.Color(subtle),
+		Surface:     lipgloss.Color(surface),
+		Selection:   lipgloss.Color(selection),
+		Focus:       lipgloss.Color("63"),
+		Error:       lipgloss.Color(red),
+		Warning:     lipgloss.Color(yellow),
+		Success:     lipgloss.Color(green),
+		Highlight:   lipgloss.Color(peach),
+		Accent:      lipgloss.Color(blue),
+		Literal:     lipgloss.Color(mauve),
+		Punctuation: lipgloss.Color(punctuation),
+		Flame: []lipgloss.TerminalColor{
+			lipgloss.Color(red), lipgloss.Color(peach), lipgloss.Color(yellow), lipgloss.Color(maroon), lipgloss.Color(pink),
+		},
+	}
+}
+
+// Themes are the palettes that can be picked in the config.
+var Themes = map[string]Theme{
+	"catppuccin-latte": catppuccin("#4c4f69", "#9ca0b0", "#7c7f93", "#e6e9ef", "#ccd0da",
+		"#d20f39", "#df8e1d", "#40a02b", "#fe640b", "#1e66f5", "#8839ef", "#ea76cb", "#e64553"),
+	"catppuccin-frappe": catppuccin("#c6d0f5", "#737994", "#949cbb", "#303446", "#414559",
+		"#e78284", "#e5c890", "#a6d189", "#ef9f76", "#8caaee", "#ca9ee6", "#f4b8e4", "#ea999c"),
+	"catppuccin-macchiato": catppuccin("#cad3f5", "#6e738d", "#939ab7", "#24273a", "#363a4f",
+		"#ed8796", "#eed49f", "#a6da95", "#f5a97f", "#8aadf4", "#c6a0f6", "#f5bde6", "#ee99a0"),
+	"catppuccin-mocha": catppuccin("#cdd6f4", "#6c7086", "#9399b2", "#1e1e2e", "#313244",
+		"#f38ba8", "#f9e2af", "#a6e3a1", "#fab387", "#89b4fa", "#cba6f7", "#f5c2e7", "#eba0ac"),
+	// For light terminal backgrounds, in the 256 color palette
+	"light": {
+		Text:        lipgloss.Color("235"),
+		Muted:       lipgloss.Color("244"),
+		Subtle:      lipgloss.Color("248"),
+		Surface:     lipgloss.Color("254"),
+		Selection:   lipgloss.Color("252"),
+		Focus:       lipgloss.Color("27"),
+		Error:       lipgloss.Color("160"),
+		Warning:     lipgloss.Color("136"),
+		Success:     lipgloss.Color("28"),
+		Highlight:   lipgloss.Color("166"),
+		Accent:      lipgloss.Color("25"),
+		Literal:     lipgloss.Color("91"),
+		Punctuation: lipgloss.Color("242"),
+		Flame: []lipgloss.TerminalColor{
+			lipgloss.Color("210"), lipgloss.Color("216"), lipgloss.Color("222"), lipgloss.Color("217"), lipgloss.Color("223"),
+		},
+	},
+	// Bright basic colors that most terminals show clearly
+	"high-contrast": {
+		Text:        lipgloss.Color("15"),
+		Muted:       lipgloss.Color("250"),
+		Subtle:      lipgloss.Color("248"),
+		Surface:     lipgloss.Color("0"),
+		Selection:   lipgloss.Color("15"),
+		Focus:       lipgloss.Color("11"),
+		Error:       lipgloss.Color("9"),
+		Warning:     lipgloss.Color("11"),
+		Success:     lipgloss.Color("10"),
+		Highlight:   lipgloss.Color("208"),
+		Accent:      lipgloss.Color("14"),
+		Literal:     lipgloss.Color("13"),
+		Punctuation: lipgloss.Color("15"),
+		Flame: []lipgloss.TerminalColor{
+			lipgloss.Color("9"), lipgloss.Color("11"), lipgloss.Color("208"), lipgloss.Color("13"), lipgloss.Color("14"),
+		},
+		Reverse: true,
+	},
+	NoColorTheme: {
+		Text:        lipgloss.NoColor{},
+		Muted:       lipgloss.NoColor{},
+		Subtle:      lipgloss.NoColor{},
+		Surface:     lipgloss.NoColor{},
+		Selection:   lipgloss.NoColor{},
+		Focus:       lipgloss.NoColor{},
+		Error:       lipgloss.NoColor{},
+		Warning:     lipgloss.NoColor{},
+		Success:     lipgloss.NoColor{},
+		Highlight:   lipgloss.NoColor{},
+		Accent:      lipgloss.NoColor{},
+		Literal:     lipgloss.NoColor{},
+		Punctuation: lipgloss.NoColor{},
+		Flame:       []lipgloss.TerminalColor{lipgloss.NoColor{}},
+		Reverse:     true,
+	},
+}
+
+// theme is the palette in use.
+var theme = Themes[DefaultTheme]
+
+// SetTheme picks the palette to draw with. NO_COLOR overrides it.
+func SetTheme(name string) error {
+	if os.Getenv("NO_COLOR") != "" {
+		name = NoColorTheme
+	}
+	if name == "" {
+		name = DefaultTheme
+	}
+	t, ok := Themes[name]
+	if !ok {
+		names := lo.Keys(Themes)
+		slices.Sort(names)
+		return fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	theme = t
+	return nil
+}
+
+// Selected marks the selected row of a list.
+func (t Theme) Selected(s lipgloss.Style) lipgloss.Style {
+	if t.Reverse {
+		return s.Reverse(true)
+	}
+	return s.Background(t.Selection)
+}
+
+// Cursor marks the row under the trace list's cursor, which may also be
+// the selected trace.
+func (t Theme) Cursor(s lipgloss.Style) lipgloss.Style {
+	if t.Reverse {
+		return s.Reverse(true)
+	}
+	return s.Background(t.Surface)
+}
+
+// Viewed marks the trace whose details are shown.
+func (t Theme) Viewed(s lipgloss.Style) lipgloss.Style {
+	if t.Reverse {
+		return s.Underline(true)
+	}
+	return s.Background(t.Selection)
+}
+
+// Border is the border color of a pane.
+func (t Theme) Border(focused bool) lipgloss.TerminalColor {
+	if focused {
+		return t.Focus
+	}
+	return t.Muted
+}
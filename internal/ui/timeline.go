@@ -10,18 +10,35 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/evertras/bubble-table/table"
 	"github.com/samber/lo"
+	"github.com/zopu/tracey/internal/analysis"
 	"github.com/zopu/tracey/internal/aws"
 )
 
+// Rows keep their span's ID under this key, which isn't shown
+const timelineIDKey = "id"
+
 type timeline struct {
 	tableModel table.Model
+	columns    func(width int) []table.Column
 }
 
 func (t timeline) View() string {
 	return t.tableModel.View()
 }
 
-func newTimeline(td aws.TraceDetails, width int) timeline {
+// newTimeline lists a trace's spans in order. Spans on the critical path, if
+// given, are highlighted with their self and waiting times, and spans that
+// matched rules are annotated.
+func newTimeline(td aws.TraceDetails, width int, criticalPath []analysis.CriticalSpan, matches []analysis.RuleMatch) timeline {
+	critical := lo.SliceToMap(criticalPath, func(c analysis.CriticalSpan) (string, analysis.CriticalSpan) {
+		return c.Span.ID, c
+	})
+	spanMatches := map[string][]analysis.RuleMatch{}
+	for _, m := range matches {
+		for _, id := range m.SpanIDs {
+			spanMatches[id] = append(spanMatches[id], m)
+		}
+	}
 	rows := make([]timeLineRow, 0)
 	for _, segment := range td.Segments {
 		if segment.ParentID != "" {
@@ -41,6 +58,7 @@ func newTimeline(td aws.TraceDetails, width int) timeline {
 
 		duration := segment.EndTime.Time().Sub(segment.StartTime.Time())
 		rows = append(rows, timeLineRow{
+			id:        segment.ID,
 			startTime: time.Duration(0),
 			duration:  duration,
 			details:   details,
@@ -51,49 +69,96 @@ func newTimeline(td aws.TraceDetails, width int) timeline {
 		}
 	}
 
-	columns := []table.Column{
-		table.NewColumn("Start Time", "Start Time", 15),
-		table.NewColumn("Duration", "Duration", 15),
-		table.NewColumn("Details", "Details", width-34),
-	}
+	criticalStyle := lipgloss.NewStyle().Foreground(theme.Highlight).Bold(true)
 	tableRows := lo.Map(rows, func(row timeLineRow, _ int) table.Row {
-		return table.NewRow(table.RowData{
-			"Start Time": row.startTime.String(),
-			"Duration":   row.duration.String(),
-			"Details":    strings.Join(row.details, "\n"),
+		details := row.details
+		c, onPath := critical[row.id]
+		if onPath {
+			details = append(details, fmt.Sprintf("▲ critical path: %s self, %s waiting",
+				formatMillis(c.Self), formatMillis(c.Waiting)))
+		}
+		for _, m := range spanMatches[row.id] {
+			details = append(details, ruleAnnotation(m))
+		}
+		r := table.NewRow(table.RowData{
+			timelineIDKey: row.id,
+			"Start Time":  row.startTime.String(),
+			"Duration":    row.duration.String(),
+			"Details":     strings.Join(details, "\n"),
 		})
+		switch {
+		case onPath:
+			r = r.WithStyle(criticalStyle)
+		case len(spanMatches[row.id]) > 0:
+			r = r.WithStyle(severityStyle(analysis.MostSevere(spanMatches[row.id])))
+		}
+		return r
 	})
-	t := table.New(columns).
+	t := table.New(timelineColumns(width)).
 		WithRows(tableRows).
+		WithKeyMap(scrollableTableKeyMap()).
 		WithMultiline(true).
 		WithBaseStyle(
 			lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#c6d0f5")).
-				BorderForeground(lipgloss.Color("240")).
+				Foreground(theme.Text).
+				BorderForeground(theme.Muted).
 				Bold(false)).
 		HeaderStyle(
 			lipgloss.NewStyle().
 				Bold(true))
-	return timeline{tableModel: t}
+	return timeline{tableModel: t, columns: timelineColumns}
+}
+
+// renderCriticalPath lists the spans on the critical path, indented by
+// depth, with where their time on the path went.
+func renderCriticalPath(path []analysis.CriticalSpan) string {
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Foreground(theme.Muted).
+		Render(fmt.Sprintf("  %8s  %8s  %s", "Self", "Waiting", "Span")) + "\n")
+	for _, c := range path {
+		fmt.Fprintf(&b, "  %8s  %8s  %s%s\n",
+			formatMillis(c.Self), formatMillis(c.Waiting), strings.Repeat("  ", c.Depth), c.Span.Name)
+	}
+	return b.String()
+}
+
+func timelineColumns(width int) []table.Column {
+	return []table.Column{
+		table.NewColumn("Start Time", "Start Time", 15),
+		table.NewColumn("Duration", "Duration", 15),
+		table.NewColumn("Details", "Details", max(width-34, 10)),
+	}
+}
+
+// WithSize lays the timeline out for a new pane width, showing pageSize rows
+// per page.
+func (t timeline) WithSize(width, pageSize int) timeline {
+	t.tableModel = t.tableModel.
+		WithColumns(t.columns(width)).
+		WithPageSize(pageSize)
+	return t
 }
 
 func (t timeline) Update(msg tea.Msg) (timeline, tea.Cmd) {
 	switch msg := msg.(type) { //nolint:gocritic // standard pattern
 	case tea.KeyMsg:
 		tb, cmd := t.tableModel.Update(msg)
-		return timeline{tableModel: tb}, cmd
+		t.tableModel = tb
+		return t, cmd
 	}
 	return t, nil
 }
 
+// HighlightedSpanID is the ID of the span in the highlighted row, if known.
+func (t timeline) HighlightedSpanID() string {
+	id, _ := t.tableModel.HighlightedRow().Data[timelineIDKey].(string)
+	return id
+}
+
 func (t timeline) SetFocus(focus bool) timeline {
-	color := "240"
-	if focus {
-		color = "63"
-	}
 	t.tableModel = t.tableModel.WithBaseStyle(
-		lipgloss.NewStyle().BorderForeground(lipgloss.Color(color)).
-			Foreground(lipgloss.Color("#c6d0f5")).
+		lipgloss.NewStyle().BorderForeground(theme.Border(focus)).
+			Foreground(theme.Text).
 			Bold(false)).
 		Focused(focus)
 	return t
@@ -115,6 +180,7 @@ func getSubsegmentRows(subsegment aws.SubSegment, startTime time.Time) []timeLin
 		}
 	})
 	rows = append(rows, timeLineRow{
+		id:        subsegment.ID,
 		startTime: timeOffset,
 		duration:  duration,
 		details:   details,
@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evertras/bubble-table/table"
+	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/config"
+)
+
+const annotationColumnPrefix = "annotation."
+
+var defaultTraceColumns = []string{
+	"id", "start_time", "client_ip", "status", "method", "response_time", "path",
+}
+
+const defaultTraceSort = "-start_time"
+
+// traceColumn describes one column of the trace list. Columns without a
+// width share whatever space is left over.
+type traceColumn struct {
+	key   string
+	title string
+	width int
+	value func(aws.TraceSummary) string
+	// compare orders traces for sorting, falling back to comparing values
+	compare func(a, b aws.TraceSummary) int
+}
+
+func (c traceColumn) tableColumn(sortIndicator string) table.Column {
+	title := c.title + sortIndicator
+	if c.width == 0 {
+		return table.NewFlexColumn(c.key, title, 1)
+	}
+	return table.NewColumn(c.key, title, max(c.width, len(title)))
+}
+
+func (c traceColumn) cmp(a, b aws.TraceSummary) int {
+	if c.compare != nil {
+		return c.compare(a, b)
+	}
+	return strings.Compare(c.value(a), c.value(b))
+}
+
+func formatMillis(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+var traceColumns = map[string]traceColumn{
+	"id": {
+		title: "ID",
+		width: 35,
+		value: aws.TraceSummary.ID,
+	},
+	"start_time": {
+		title: "Start",
+		width: 14,
+		value: func(t aws.TraceSummary) string {
+			return t.StartTime().Format("01-02 15:04:05")
+		},
+		compare: func(a, b aws.TraceSummary) int {
+			return a.StartTime().Compare(b.StartTime())
+		},
+	},
+	"client_ip": {
+		title: "Client IP",
+		width: 15,
+		value: aws.TraceSummary.ClientIP,
+	},
+	"status": {
+		title: "Status",
+		width: 8,
+		value: aws.TraceSummary.StatusText,
+		// Faults sort above errors, so sorting descending puts problems first
+		compare: func(a, b aws.TraceSummary) int {
+			return cmp.Or(
+				cmp.Compare(statusSeverity(a), statusSeverity(b)),
+				cmp.Compare(a.Status(), b.Status()),
+			)
+		},
+	},
+	"method": {
+		title: "Method",
+		width: 7,
+		value: aws.TraceSummary.Method,
+	},
+	"response_time": {
+		title: "Response",
+		width: 9,
+		value: func(t aws.TraceSummary) string {
+			return formatMillis(t.ResponseTime())
+		},
+		compare: func(a, b aws.TraceSummary) int {
+			return cmp.Compare(a.ResponseTime(), b.ResponseTime())
+		},
+	},
+	"duration": {
+		title: "Duration",
+		width: 9,
+		value: func(t aws.TraceSummary) string {
+			return formatMillis(t.Duration())
+		},
+		compare: func(a, b aws.TraceSummary) int {
+			return cmp.Compare(a.Duration(), b.Duration())
+		},
+	},
+	// Traces without HTTP data show their entry point instead of a path
+	"path": {
+		title: "Path",
+		value: aws.TraceSummary.Operation,
+	},
+	"entry_point": {
+		title: "Entry Point",
+		width: 30,
+		value: aws.TraceSummary.EntryPoint,
+	},
+	"services": {
+		title: "Services",
+		width: 30,
+		value: func(t aws.TraceSummary) string {
+			return strings.Join(t.ServiceNames(), ",")
+		},
+	},
+	"user": {
+		title: "User",
+		width: 15,
+		value: func(t aws.TraceSummary) string {
+			return strings.Join(t.UserNames(), ",")
+		},
+	},
+	"root_cause": {
+		title: "Root Cause",
+		value: aws.TraceSummary.RootCause,
+	},
+}
+
+func statusSeverity(t aws.TraceSummary) int {
+	switch {
+	case t.HasFault():
+		return 3
+	case t.HasThrottle():
+		return 1
+	case t.HasError():
+		return 2
+	}
+	return 0
+}
+
+func annotationColumn(name string) traceColumn {
+	return traceColumn{
+		title: name,
+		width: 15,
+		value: func(t aws.TraceSummary) string {
+			return t.Annotation(name)
+		},
+		// Numeric annotations sort numerically
+		compare: func(a, b aws.TraceSummary) int {
+			av, aErr := strconv.ParseFloat(a.Annotation(name), 64)
+			bv, bErr := strconv.ParseFloat(b.Annotation(name), 64)
+			if aErr == nil && bErr == nil {
+				return cmp.Compare(av, bv)
+			}
+			return strings.Compare(a.Annotation(name), b.Annotation(name))
+		},
+	}
+}
+
+func lookupTraceColumn(key string) (traceColumn, error) {
+	if name, ok := strings.CutPrefix(key, annotationColumnPrefix); ok && name != "" {
+		c := annotationColumn(name)
+		c.key = key
+		return c, nil
+	}
+	c, ok := traceColumns[key]
+	if !ok {
+		return traceColumn{}, fmt.Errorf("unknown trace list column: %s", key)
+	}
+	c.key = key
+	return c, nil
+}
+
+// parseTraceListConfig resolves the configured columns and sort order.
+func parseTraceListConfig(cfg config.TraceList) ([]traceColumn, traceSort, error) {
+	keys := cfg.Columns
+	if len(keys) == 0 {
+		keys = defaultTraceColumns
+	}
+	for _, name := range cfg.Annotations {
+		if key := annotationColumnPrefix + name; !slices.Contains(keys, key) {
+			keys = append(slices.Clone(keys), key)
+		}
+	}
+	columns := make([]traceColumn, len(keys))
+	for i, key := range keys {
+		c, err := lookupTraceColumn(key)
+		if err != nil {
+			return nil, traceSort{}, err
+		}
+		columns[i] = c
+	}
+
+	sortKey := cfg.Sort
+	if sortKey == "" {
+		sortKey = defaultTraceSort
+	}
+	sortKey, desc := strings.CutPrefix(sortKey, "-")
+	// The sort column doesn't have to be one that's shown
+	sortColumn, err := lookupTraceColumn(sortKey)
+	if err != nil {
+		return nil, traceSort{}, fmt.Errorf("invalid trace list sort: %w", err)
+	}
+	return columns, traceSort{column: sortColumn, desc: desc}, nil
+}
+
+type traceSort struct {
+	column traceColumn
+	desc   bool
+}
+
+func (s traceSort) cmp(a, b aws.TraceSummary) int {
+	if s.desc {
+		return s.column.cmp(b, a)
+	}
+	return s.column.cmp(a, b)
+}
+
+func (s traceSort) indicator(key string) string {
+	if key != s.column.key {
+		return ""
+	}
+	if s.desc {
+		return " ▼"
+	}
+	return " ▲"
+}
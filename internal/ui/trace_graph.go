@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/zopu/tracey/internal/analysis"
+)
+
+// renderTraceGraph draws the service graph as a tree of boxes, with an arrow
+// for each call labelled with the call count and total time. Services that
+// are reached more than once are only expanded the first time.
+func renderTraceGraph(g analysis.ServiceGraph) string {
+	if len(g.Nodes) == 0 {
+		return "No services found in this trace"
+	}
+	expanded := map[string]bool{}
+	lines := make([]string, 0)
+	for _, root := range g.Roots() {
+		lines = append(lines, renderGraphSubtree(g, root, expanded)...)
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func renderGraphSubtree(g analysis.ServiceGraph, node analysis.GraphNode, expanded map[string]bool) []string {
+	edges := g.EdgesFrom(node.ID)
+	// Only note repeats for services whose calls would otherwise be shown
+	repeated := expanded[node.ID] && len(edges) > 0
+	expanded[node.ID] = true
+	if repeated {
+		edges = nil
+	}
+	lines := graphBox(node, len(edges) > 0, repeated)
+
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	for i, edge := range edges {
+		last := i == len(edges)-1
+		target, ok := g.Node(edge.To)
+		if !ok {
+			continue
+		}
+		arrowStyle := mutedStyle
+		if edge.Faults > 0 || edge.Errors > 0 {
+			arrowStyle = lipgloss.NewStyle().Foreground(theme.Error)
+		}
+		lines = append(lines, "  │ "+arrowStyle.Render(edge.Label()))
+
+		child := renderGraphSubtree(g, target, expanded)
+		for j, line := range child {
+			var prefix string
+			switch {
+			case j == 1 && last:
+				prefix = "  └" + arrowStyle.Render("───▶ ")
+			case j == 1:
+				prefix = "  ├" + arrowStyle.Render("───▶ ")
+			case j < 1 || !last:
+				prefix = "  │     "
+			default:
+				prefix = "        "
+			}
+			lines = append(lines, prefix+line)
+		}
+	}
+	return lines
+}
+
+// graphBox draws a node. The bottom border has a tee where the arrows to
+// downstream services leave from.
+func graphBox(node analysis.GraphNode, hasChildren, repeated bool) []string {
+	name := node.Name
+	if repeated {
+		name += " (see above)"
+	}
+	width := max(runewidth.StringWidth(name), runewidth.StringWidth(node.Type)) + 2
+
+	border := lipgloss.NewStyle().Foreground(theme.Focus)
+	if node.Inferred {
+		border = lipgloss.NewStyle().Foreground(theme.Muted)
+	}
+	pad := func(s string) string {
+		return s + strings.Repeat(" ", width-runewidth.StringWidth(s)-1)
+	}
+
+	bottom := "└" + strings.Repeat("─", width) + "┘"
+	if hasChildren {
+		bottom = "└─┬" + strings.Repeat("─", width-2) + "┘"
+	}
+	lines := []string{
+		border.Render("┌" + strings.Repeat("─", width) + "┐"),
+		border.Render("│") + " " + lipgloss.NewStyle().Bold(true).Render(pad(name)) + border.Render("│"),
+	}
+	if node.Type != "" {
+		lines = append(lines,
+			border.Render("│")+" "+lipgloss.NewStyle().Foreground(theme.Muted).Render(pad(node.Type))+border.Render("│"))
+	}
+	return append(lines, border.Render(bottom))
+}
+
+// ExportedMsg reports the result of writing an export to disk.
+type ExportedMsg struct {
+	Path string
+	Err  error
+}
+
+func (m ExportedMsg) Status() string {
+	if m.Err != nil {
+		return "Export failed: " + m.Err.Error()
+	}
+	return "Exported to " + m.Path
+}
+
+// exportFile writes content to path in the current directory.
+func exportFile(path, content string) tea.Cmd {
+	return func() tea.Msg {
+		err := os.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			err = fmt.Errorf("writing %s: %w", path, err)
+		}
+		return ExportedMsg{Path: path, Err: err}
+	}
+}
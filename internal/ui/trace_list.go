@@ -2,25 +2,38 @@ package ui
 
 import (
 	"context"
+	"fmt"
 	"regexp"
+	"slices"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/lipgloss/list"
+	"github.com/evertras/bubble-table/table"
 	"github.com/samber/lo"
 	"github.com/samber/mo"
+	"github.com/zopu/tracey/internal/analysis"
 	"github.com/zopu/tracey/internal/aws"
+	"github.com/zopu/tracey/internal/config"
+	"github.com/zopu/tracey/internal/keymap"
 	"github.com/zopu/tracey/internal/store"
 )
 
 type TraceSummaryMsg struct {
+	Query           aws.TraceQuery
 	NextToken       mo.Option[string]
 	Traces          []aws.TraceSummary
 	ShouldFetchMore bool
 }
 
-func FetchTraceSummaries(store *store.Store, pathFilters []regexp.Regexp, nextToken mo.Option[string]) tea.Msg {
-	result, err := aws.FetchTraceSummaries(context.Background(), nextToken)
+func FetchTraceSummaries(
+	store *store.Store,
+	query aws.TraceQuery,
+	pathFilters []regexp.Regexp,
+	nextToken mo.Option[string],
+) tea.Msg {
+	result, err := aws.FetchTraceSummaries(context.Background(), query, nextToken)
 	if err != nil {
 		return ErrorMsg{Msg: err.Error()}
 	}
@@ -42,41 +55,194 @@ outer:
 	shouldFetchMore := result.NextToken.IsPresent() && store.Size() < 20
 
 	return TraceSummaryMsg{
+		Query:           query,
 		Traces:          filtered,
 		NextToken:       result.NextToken,
 		ShouldFetchMore: shouldFetchMore,
 	}
 }
 
+const traceListRows = 10
+
+const rulesColumnKey = "rules"
+
 type TraceList struct {
 	Traces    []aws.TraceSummary
 	NextToken mo.Option[string]
 	Width     int
-	selected  mo.Option[int]
-	focused   bool
-	cursor    int
+	// The X-Ray filter expression the traces were fetched with
+	Filter string
+	// Name of the X-Ray group the traces are scoped to, if any
+	Group string
+	// Set when the traces are from a fixed time range rather than up to now
+	TimeRange string
+	// Set when the traces look back over something other than the default
+	// window, e.g. "30m"
+	Window string
+	// Shows a column of badges for the configured rules each trace matched
+	ShowRules bool
+	// Why some traces haven't been checked against the rules, if any were
+	// left out
+	RulesStatus string
+	ruleMatches map[string][]analysis.RuleMatch
+	// IDs of bookmarked traces, which are marked with a star
+	Bookmarked  map[string]bool
+	Keys        keymap.ListKeys
+	allTraces   []aws.TraceSummary
+	localFilter mo.Option[TraceFilter]
+	columns     []traceColumn
+	sort        traceSort
+	selected    mo.Option[string]
+	// A trace marked to compare others with
+	baseline mo.Option[string]
+	focused  bool
+	cursor   int
 }
 
-func NewTraceList() TraceList {
+func NewTraceList(cfg config.TraceList) (TraceList, error) {
+	columns, sort, err := parseTraceListConfig(cfg)
+	if err != nil {
+		return TraceList{}, err
+	}
 	return TraceList{
-		Traces: []aws.TraceSummary{},
+		Traces:      []aws.TraceSummary{},
+		Keys:        keymap.Default().List,
+		ruleMatches: map[string][]analysis.RuleMatch{},
+		columns:     columns,
+		sort:        sort,
+	}, nil
+}
+
+// TraceFilter narrows the trace list down to matching traces, without
+// fetching anything new.
+type TraceFilter struct {
+	Description string
+	Match       func(aws.TraceSummary) bool
+}
+
+type FilterTracesMsg struct {
+	Filter TraceFilter
+}
+
+// AddRuleMatches records the rules matched by newly checked traces.
+func (tl *TraceList) AddRuleMatches(matches map[string][]analysis.RuleMatch) {
+	if tl.ruleMatches == nil {
+		tl.ruleMatches = map[string][]analysis.RuleMatch{}
+	}
+	for id, m := range matches {
+		tl.ruleMatches[id] = m
+	}
+	// Matches arriving after ! was pressed change what it shows
+	tl.applyLocalFilter()
+}
+
+// SetTraces replaces the traces shown, keeping the cursor on the same trace.
+func (tl *TraceList) SetTraces(traces []aws.TraceSummary) {
+	tl.allTraces = traces
+	tl.applyLocalFilter()
+}
+
+// AllTraces returns every loaded trace, including those hidden by a local
+// filter.
+func (tl TraceList) AllTraces() []aws.TraceSummary {
+	return tl.allTraces
+}
+
+// HighlightedTrace is the trace under the cursor.
+func (tl TraceList) HighlightedTrace() mo.Option[aws.TraceSummary] {
+	if tl.cursor < 0 || tl.cursor >= len(tl.Traces) {
+		return mo.None[aws.TraceSummary]()
+	}
+	return mo.Some(tl.Traces[tl.cursor])
+}
+
+func (tl *TraceList) SetLocalFilter(filter mo.Option[TraceFilter]) {
+	tl.localFilter = filter
+	tl.applyLocalFilter()
+}
+
+func (tl *TraceList) applyLocalFilter() {
+	var cursorID string
+	if tl.cursor >= 0 && tl.cursor < len(tl.Traces) {
+		cursorID = tl.Traces[tl.cursor].ID()
+	}
+	if f, ok := tl.localFilter.Get(); ok {
+		tl.Traces = lo.Filter(tl.allTraces, func(t aws.TraceSummary, _ int) bool {
+			return f.Match(t)
+		})
+	} else {
+		tl.Traces = append([]aws.TraceSummary{}, tl.allTraces...)
+	}
+	tl.sortTraces()
+	tl.MoveCursor(0)
+	if cursorID != "" {
+		tl.moveCursorTo(cursorID)
 	}
 }
 
+func (tl *TraceList) sortTraces() {
+	slices.SortStableFunc(tl.Traces, tl.sort.cmp)
+}
+
+// Select marks a trace as the one shown in the details pane, moving the
+// cursor to it if it's in the list.
+func (tl *TraceList) Select(id string) {
+	tl.selected = mo.Some(id)
+	tl.moveCursorTo(id)
+}
+
+// ClearBaseline unmarks a trace as the baseline, if it's still the one.
+func (tl *TraceList) ClearBaseline(id string) {
+	if tl.baseline.OrEmpty() == id {
+		tl.baseline = mo.None[string]()
+	}
+}
+
+func (tl *TraceList) moveCursorTo(id string) {
+	if i := slices.IndexFunc(tl.Traces, func(t aws.TraceSummary) bool {
+		return t.ID() == id
+	}); i >= 0 {
+		tl.cursor = i
+	}
+}
+
+// cycleSort sorts by the next visible column, or reverses the current sort.
+func (tl *TraceList) cycleSort(reverse bool) {
+	var cursorID string
+	if tl.cursor >= 0 && tl.cursor < len(tl.Traces) {
+		cursorID = tl.Traces[tl.cursor].ID()
+	}
+	if reverse {
+		tl.sort.desc = !tl.sort.desc
+	} else {
+		i := slices.IndexFunc(tl.columns, func(c traceColumn) bool {
+			return c.key == tl.sort.column.key
+		})
+		tl.sort = traceSort{column: tl.columns[(i+1)%len(tl.columns)]}
+	}
+	tl.sortTraces()
+	tl.moveCursorTo(cursorID)
+}
+
 func (tl *TraceList) MoveCursor(amount int) {
 	tl.cursor += amount
-	if tl.cursor < 0 {
-		tl.cursor = 0
-	}
 	if tl.cursor >= len(tl.Traces) {
 		tl.cursor = len(tl.Traces) - 1
 	}
+	if tl.cursor < 0 {
+		tl.cursor = 0
+	}
 }
 
 func (tl *TraceList) SetFocus(focus bool) {
 	tl.focused = focus
 }
 
+// IsCapturingInput is true while a text input in the list has focus.
+func (tl TraceList) IsCapturingInput() bool {
+	return false
+}
+
 type ListSelectionMsg struct {
 	ID aws.TraceID
 }
@@ -85,23 +251,113 @@ type ListAtEndMsg struct{}
 
 func (tl *TraceList) Update(msg tea.Msg) tea.Cmd {
 	if msg, ok := msg.(tea.KeyMsg); ok {
-		switch msg.String() {
-		case "up", "k":
+		keys := tl.Keys
+		switch {
+		case key.Matches(msg, keys.Up):
 			tl.MoveCursor(-1)
-		case "ctrl+u":
+		case key.Matches(msg, keys.PageUp):
 			tl.MoveCursor(-10)
-		case "down", "j":
+		case key.Matches(msg, keys.Down):
 			tl.MoveCursor(1)
-		case "ctrl+d":
+		case key.Matches(msg, keys.PageDown):
 			tl.MoveCursor(10)
+		case key.Matches(msg, keys.Sort):
+			tl.cycleSort(false)
+		case key.Matches(msg, keys.ReverseSort):
+			tl.cycleSort(true)
+		case key.Matches(msg, keys.ClearFilter):
+			if tl.localFilter.IsPresent() {
+				tl.SetLocalFilter(mo.None[TraceFilter]())
+				return nil
+			}
+			if tl.Filter != "" || tl.TimeRange != "" {
+				return func() tea.Msg {
+					return SetTraceFilterMsg{}
+				}
+			}
 
-		case "enter", " ":
-			tl.selected = mo.Some(tl.cursor)
+		case key.Matches(msg, keys.Select):
+			if len(tl.Traces) == 0 {
+				return nil
+			}
+			id := tl.Traces[tl.cursor].ID()
+			tl.selected = mo.Some(id)
+			return func() tea.Msg {
+				return ListSelectionMsg{ID: aws.TraceID(id)}
+			}
+
+		case key.Matches(msg, keys.Baseline):
+			if len(tl.Traces) == 0 {
+				return nil
+			}
+			id := tl.Traces[tl.cursor].ID()
+			if tl.baseline.OrEmpty() == id {
+				tl.baseline = mo.None[string]()
+			} else {
+				tl.baseline = mo.Some(id)
+			}
+
+		case key.Matches(msg, keys.Compare):
+			baseline, ok := tl.baseline.Get()
+			if !ok || len(tl.Traces) == 0 || tl.Traces[tl.cursor].ID() == baseline {
+				return nil
+			}
+			id := tl.Traces[tl.cursor].ID()
+			tl.selected = mo.Some(id)
+			return func() tea.Msg {
+				return CompareTracesMsg{Baseline: aws.TraceID(baseline), Other: aws.TraceID(id)}
+			}
+
+		case key.Matches(msg, keys.ProfileRoute):
+			if len(tl.Traces) == 0 {
+				return nil
+			}
+			msg := profileRoute(tl.Traces[tl.cursor], tl.AllTraces())
+			return func() tea.Msg {
+				return msg
+			}
+
+		case key.Matches(msg, keys.Bookmark):
+			if len(tl.Traces) == 0 {
+				return nil
+			}
+			id := tl.Traces[tl.cursor].ID()
 			return func() tea.Msg {
-				return ListSelectionMsg{ID: aws.TraceID(tl.Traces[tl.cursor].ID())}
+				return EditBookmarkMsg{ID: id}
 			}
 
-		case "tab":
+		case key.Matches(msg, keys.Share, keys.Open):
+			if len(tl.Traces) == 0 {
+				return nil
+			}
+			id := aws.TraceID(tl.Traces[tl.cursor].ID())
+			if key.Matches(msg, keys.Open) {
+				return func() tea.Msg {
+					return OpenTraceMsg{ID: id}
+				}
+			}
+			return func() tea.Msg {
+				return ShareTraceMsg{ID: id}
+			}
+
+		case key.Matches(msg, keys.Flagged):
+			// The map is shared by every copy of the list and filled in as
+			// matches arrive, so the filter sees later matches too
+			matches := tl.ruleMatches
+			tl.SetLocalFilter(mo.Some(TraceFilter{
+				Description: "traces flagged by rules",
+				Match: func(t aws.TraceSummary) bool {
+					return len(matches[t.ID()]) > 0
+				},
+			}))
+
+		case key.Matches(msg, keys.ProfileAll):
+			msg := ProfileTracesMsg{Description: "listed traces", Traces: tl.Traces}
+			return func() tea.Msg {
+				return msg
+			}
+
+		case key.Matches(msg, keys.NextPane):
 			return func() tea.Msg {
 				return SelectNextPaneMsg{}
 			}
@@ -124,78 +380,133 @@ func (tl TraceList) View() string {
 		return "Looking for traces...\n"
 	}
 
-	s := "No trace selected"
-	if tl.selected.IsPresent() {
-		s = listEnumeratorStyle().Render("  ")
-		title := tl.Traces[tl.selected.MustGet()].Title()
-		s += tl.StyleItem(tl.selected.MustGet()).Render(title)
+	index := -1
+	if id, ok := tl.selected.Get(); ok {
+		index = slices.IndexFunc(tl.Traces, func(t aws.TraceSummary) bool {
+			return t.ID() == id
+		})
 	}
+	if index < 0 {
+		return lipgloss.NewStyle().
+			Width(tl.Width - 2).
+			Height(1).
+			BorderStyle(lipgloss.NormalBorder()).
+			Render("No trace selected")
+	}
+
+	return tl.table(index, index+1).
+		WithHeaderVisibility(false).
+		View()
+}
 
-	style := lipgloss.NewStyle().
-		Width(tl.Width - 2).
-		Height(1).
-		BorderStyle(lipgloss.NormalBorder())
-	return style.Render(s)
+func (tl TraceList) filterDescription() string {
+	parts := make([]string, 0, 4)
+	if tl.Group != "" {
+		parts = append(parts, "Group: "+tl.Group)
+	}
+	if tl.TimeRange != "" {
+		parts = append(parts, "Time: "+tl.TimeRange)
+	} else if tl.Window != "" {
+		parts = append(parts, "Last: "+tl.Window)
+	}
+	if tl.Filter != "" {
+		parts = append(parts, "Filter: "+tl.Filter)
+	}
+	if f, ok := tl.localFilter.Get(); ok {
+		parts = append(parts, "Showing: "+f.Description)
+	}
+	return strings.Join(parts, " | ")
 }
 
 func (tl TraceList) ViewFocused() string {
 	if len(tl.Traces) == 0 {
+		if footer := tl.filterDescription(); footer != "" {
+			return fmt.Sprintf("No traces yet. %s (x: Clear)\n\n", footer)
+		}
 		return "Looking for traces...\n\n"
 	}
 
-	tIDs := lo.Map(tl.Traces, func(summary aws.TraceSummary, _ int) string {
-		// I'd expect lipgloss inline styling to truncate these to the width, but it doesn't,
-		// so we have to do it here.
-		t := summary.Title()
-		maxLen := tl.Width - 6
-		if len(t) > maxLen {
-			return t[:maxLen-3] + "..."
-		}
-		return t
-	})
+	start := max(0, min(tl.cursor-traceListRows/2, len(tl.Traces)-traceListRows))
+	end := min(len(tl.Traces), start+traceListRows)
+	t := tl.table(start, end).
+		WithHighlightedRow(tl.cursor - start).
+		Focused(true).
+		WithMinimumHeight(traceListRows + 4)
+	if footer := tl.footer(); footer != "" {
+		t = t.WithStaticFooter(footer).
+			WithMinimumHeight(traceListRows + 6)
+	}
+	return t.View()
+}
 
-	start := max(0, tl.cursor-5)
-	end := min(len(tIDs), start+10)
-	l := list.New(tIDs[start:end]).
-		EnumeratorStyle(listEnumeratorStyle()).
-		ItemStyleFunc(func(_ list.Items, i int) lipgloss.Style {
-			return tl.StyleItem(i + start)
-		})
+func (tl TraceList) footer() string {
+	parts := make([]string, 0, 2)
+	if filter := tl.filterDescription(); filter != "" {
+		parts = append(parts, filter+" (x: Clear)")
+	}
+	if tl.RulesStatus != "" {
+		parts = append(parts, tl.RulesStatus)
+	}
+	return strings.Join(parts, " | ")
+}
 
-	enumerator := func(_ list.Items, i int) string {
-		prefix := ""
-		if tl.cursor == i+start {
-			prefix += "→"
+// table renders the traces between start and end.
+func (tl TraceList) table(start, end int) table.Model {
+	columns := lo.Map(tl.columns, func(c traceColumn, _ int) table.Column {
+		return c.tableColumn(tl.sort.indicator(c.key))
+	})
+	if tl.ShowRules {
+		columns = append([]table.Column{table.NewColumn(rulesColumnKey, "Rules", 8)}, columns...)
+	}
+	rows := make([]table.Row, 0, end-start)
+	for i := start; i < end; i++ {
+		trace := tl.Traces[i]
+		data := make(table.RowData, len(tl.columns))
+		for j, c := range tl.columns {
+			value := c.value(trace)
+			if j == 0 && tl.Bookmarked[trace.ID()] {
+				value = "★ " + value
+			}
+			if j == 0 && tl.baseline.OrEmpty() == trace.ID() {
+				value = "◆ " + value
+			}
+			data[c.key] = value
+		}
+		if tl.ShowRules {
+			matches := tl.ruleMatches[trace.ID()]
+			data[rulesColumnKey] = table.NewStyledCell(ruleBadges(matches), severityStyle(analysis.MostSevere(matches)))
 		}
-		return prefix + " "
+		rows = append(rows, table.NewRow(data).WithStyle(tl.StyleItem(i)))
 	}
-	s := l.Enumerator(enumerator).String()
-
-	style := lipgloss.NewStyle().
-		Width(tl.Width - 2).
-		Height(10).
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("63"))
-	return style.Render(s)
-}
 
-func listEnumeratorStyle() lipgloss.Style {
-	return lipgloss.NewStyle().Foreground(lipgloss.Color("99")).MarginRight(1)
+	return table.New(columns).
+		WithRows(rows).
+		WithTargetWidth(tl.Width).
+		WithBaseStyle(
+			lipgloss.NewStyle().
+				Foreground(theme.Text).
+				BorderForeground(theme.Border(tl.focused)).
+				Align(lipgloss.Left)).
+		HeaderStyle(
+			lipgloss.NewStyle().
+				Bold(true)).
+		HighlightStyle(
+			theme.Cursor(lipgloss.NewStyle()))
 }
 
 func (tl TraceList) StyleItem(index int) lipgloss.Style {
-	style := lipgloss.NewStyle().Foreground(lipgloss.Color("#c6d0f5")).MarginRight(1)
-	if tl.cursor == index {
-		style = style.Background(lipgloss.Color("#303446"))
-	}
-	if sel, ok := tl.selected.Get(); ok && sel == index {
-		style = style.Background(lipgloss.Color("#414559"))
-	}
-	if tl.Traces[index].HasError() {
-		style = style.Foreground(lipgloss.Color("#e78284"))
+	style := lipgloss.NewStyle().Foreground(theme.Text)
+	if sel, ok := tl.selected.Get(); ok && sel == tl.Traces[index].ID() {
+		style = theme.Viewed(style)
 	}
-	if tl.Traces[index].HasFault() {
-		style = style.Foreground(lipgloss.Color("#e78284"))
+	trace := tl.Traces[index]
+	switch {
+	case trace.HasFault():
+		style = style.Foreground(theme.Error)
+	case trace.HasThrottle():
+		style = style.Foreground(theme.Highlight).Italic(true)
+	case trace.HasError():
+		style = style.Foreground(theme.Error)
 	}
 	return style
 }